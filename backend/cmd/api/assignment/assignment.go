@@ -0,0 +1,145 @@
+// Package assignment decides which of a course's lecturers responds to a new complaint.
+package assignment
+
+import (
+	"complaints/cmd/api/models"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	RoundRobin  = "round-robin"
+	LeastOpen   = "least-open"
+	Coordinator = "coordinator"
+	// Manual marks complaints an HOD reassigned to a lecturer of their choosing
+	Manual = "manual"
+)
+
+// ErrNoLecturers is returned when a course has no lecturers to assign
+var ErrNoLecturers = errors.New("no lecturers found for the course")
+
+// Strategy picks the lecturer a complaint about a course is assigned to
+type Strategy interface {
+	// Name identifies the strategy in configuration and on assigned complaints
+	Name() string
+	// Assign returns the staff ID of the chosen lecturer and a short explanation of the choice
+	Assign(course models.Course) (lecturer string, reason string, err error)
+}
+
+// New returns the strategy with the given name
+func New(name string, complaints models.ComplaintStore) (Strategy, error) {
+	switch name {
+	case RoundRobin:
+		return &roundRobin{next: make(map[string]int)}, nil
+	case LeastOpen:
+		return &leastOpen{complaints: complaints}, nil
+	case Coordinator:
+		return &coordinator{fallback: &leastOpen{complaints: complaints}}, nil
+	}
+	return nil, fmt.Errorf("unknown assignment strategy %q", name)
+}
+
+// roundRobin hands each course's complaints to its lecturers in turn. The position is kept per
+// process, so with several API instances each one rotates on its own.
+type roundRobin struct {
+	mu   sync.Mutex
+	next map[string]int
+}
+
+func (s *roundRobin) Name() string {
+	return RoundRobin
+}
+
+func (s *roundRobin) Assign(course models.Course) (string, string, error) {
+	if len(course.Lecturers) == 0 {
+		return "", "", ErrNoLecturers
+	}
+
+	s.mu.Lock()
+	i := s.next[course.CourseCode] % len(course.Lecturers)
+	s.next[course.CourseCode] = i + 1
+	s.mu.Unlock()
+
+	reason := fmt.Sprintf("next in rotation (%d of %d) for %s", i+1, len(course.Lecturers), course.CourseCode)
+	return course.Lecturers[i], reason, nil
+}
+
+// leastOpen gives the complaint to the lecturer with the fewest complaints still in progress.
+// Ties go to whoever is listed first on the course.
+type leastOpen struct {
+	complaints models.ComplaintStore
+}
+
+func (s *leastOpen) Name() string {
+	return LeastOpen
+}
+
+func (s *leastOpen) Assign(course models.Course) (string, string, error) {
+	if len(course.Lecturers) == 0 {
+		return "", "", ErrNoLecturers
+	}
+
+	chosen := ""
+	var fewest int64
+	for _, lecturer := range course.Lecturers {
+		open, err := s.complaints.CountOpenComplaintsByStaffId(lecturer)
+		if err != nil {
+			return "", "", err
+		}
+		if chosen == "" || open < fewest {
+			chosen = lecturer
+			fewest = open
+		}
+	}
+
+	reason := fmt.Sprintf("fewest open complaints (%d) among %d lecturers", fewest, len(course.Lecturers))
+	return chosen, reason, nil
+}
+
+// coordinator sends complaints to the course coordinator, falling back to another strategy
+// when the course has no coordinator who also lectures it
+type coordinator struct {
+	fallback Strategy
+}
+
+func (s *coordinator) Name() string {
+	return Coordinator
+}
+
+func (s *coordinator) Assign(course models.Course) (string, string, error) {
+	for _, lecturer := range course.Lecturers {
+		if course.Coordinator != "" && lecturer == course.Coordinator {
+			return lecturer, "course coordinator", nil
+		}
+	}
+
+	lecturer, reason, err := s.fallback.Assign(course)
+	if err != nil {
+		return "", "", err
+	}
+	return lecturer, "no coordinator available, " + reason, nil
+}
+
+// Without returns a copy of course with the given lecturer removed, so a strategy can pick
+// someone else when that lecturer is unavailable
+func Without(course models.Course, lecturer string) models.Course {
+	var others []string
+	for _, l := range course.Lecturers {
+		if l != lecturer {
+			others = append(others, l)
+		}
+	}
+	course.Lecturers = others
+	return course
+}
+
+// Teaches reports whether lecturer is one of the course's lecturers
+func Teaches(course models.Course, lecturer string) bool {
+	for _, l := range course.Lecturers {
+		if l == lecturer {
+			return true
+		}
+	}
+	return false
+}
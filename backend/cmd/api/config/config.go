@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -14,6 +17,64 @@ import (
 type Config struct {
 	MongoURI string
 	DbName   string
+	// Store selects the repository implementation: "mongo" (the default) or "memory"
+	Store string
+	// SeedFile optionally names a JSON file of users, courses, students and lecturers for the memory store
+	SeedFile string
+	// AssignmentStrategy picks lecturers for new complaints: "least-open" (the default), "round-robin" or "coordinator"
+	AssignmentStrategy string
+	// Storage selects where uploaded files are kept: "local" (the default), "s3" or "gridfs"
+	Storage string
+	// UploadsDir is the directory the local storage keeps files in, "uploads" by default
+	UploadsDir  string
+	S3Endpoint  string
+	S3Bucket    string
+	S3Region    string
+	S3AccessKey string
+	S3SecretKey string
+	// UploadTypes lists the MIME types proof uploads may have; empty means the built-in
+	// PDF, PNG, JPEG and HEIC allowlist
+	UploadTypes []string
+	// DownloadKey signs attachment download links; it defaults to JWTKEY
+	DownloadKey string
+	// DownloadTTL is how long a signed download link stays valid, 5 minutes by default
+	DownloadTTL time.Duration
+	// Scanner checks uploads for malware: "none" (the default) or "clamd"
+	Scanner string
+	// ClamdAddress is where clamd listens, as host:port or unix:/path/to/clamd.sock
+	ClamdAddress string
+	// Mailer sends notification emails: "none" (the default) or "smtp"
+	Mailer       string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	// MailFrom is the address notification emails are sent from
+	MailFrom string
+	// AppURL is where the frontend is served, for links in emails
+	AppURL string
+	// WebhookAttempts is how many times a webhook delivery is tried, 6 by default and at most 20
+	WebhookAttempts int
+	// WebhookBackoff is the wait after a failed webhook delivery, doubling with each retry up to
+	// a day; 30s by default
+	WebhookBackoff time.Duration
+	// WebhookAllowPrivate lets webhooks be http URLs on loopback and other internal addresses,
+	// for testing against a local receiver; off by default
+	WebhookAllowPrivate bool
+	// SLALecturerDays, SLAAdvisorDays, SLAHODDays and SLASenateDays are the business days each
+	// reviewer has to deal with a complaint; 0 sets no deadline for that stage
+	SLALecturerDays int
+	SLAAdvisorDays  int
+	SLAHODDays      int
+	SLASenateDays   int
+	// SLAHolidays optionally names a file of holidays that do not count as business days
+	SLAHolidays string
+	// SLALocation is the time zone business days are counted in, the server's own by default
+	SLALocation *time.Location
+	// SLARemindBefore is how long before a deadline reviewers are reminded, 24h by default
+	SLARemindBefore time.Duration
+	// SLACheckInterval is how often deadlines are checked, 15m by default
+	SLACheckInterval time.Duration
 }
 
 // LoadEnv loads environment variables from a .env file
@@ -44,9 +105,187 @@ func GetConfig() (*Config, error) {
 
 	mongoURI := os.Getenv("MONGOURI")
 	dbName := os.Getenv("DB_NAME")
+	store := os.Getenv("STORE")
+	if store == "" {
+		store = "mongo"
+	}
+	if store != "mongo" && store != "memory" {
+		return nil, fmt.Errorf("unknown STORE %q, expected mongo or memory", store)
+	}
+
+	assignmentStrategy := os.Getenv("ASSIGNMENT_STRATEGY")
+	if assignmentStrategy == "" {
+		assignmentStrategy = "least-open"
+	}
+
+	storage := os.Getenv("STORAGE")
+	if storage == "" {
+		storage = "local"
+	}
+	if storage != "local" && storage != "s3" && storage != "gridfs" {
+		return nil, fmt.Errorf("unknown STORAGE %q, expected local, s3 or gridfs", storage)
+	}
+
+	uploadsDir := os.Getenv("UPLOADS_DIR")
+	if uploadsDir == "" {
+		uploadsDir = "uploads"
+	}
+
+	downloadKey := os.Getenv("DOWNLOAD_KEY")
+	if downloadKey == "" {
+		downloadKey = os.Getenv("JWTKEY")
+	}
+	if downloadKey == "" {
+		return nil, fmt.Errorf("DOWNLOAD_KEY or JWTKEY must be set to sign download links")
+	}
+
+	downloadTTL := 5 * time.Minute
+	if ttl := os.Getenv("DOWNLOAD_TTL"); ttl != "" {
+		parsed, err := time.ParseDuration(ttl)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid DOWNLOAD_TTL %q, expected a duration such as 5m", ttl)
+		}
+		downloadTTL = parsed
+	}
+
+	scanner := os.Getenv("SCANNER")
+	if scanner == "" {
+		scanner = "none"
+	}
+	if scanner != "none" && scanner != "clamd" {
+		return nil, fmt.Errorf("unknown SCANNER %q, expected none or clamd", scanner)
+	}
+
+	clamdAddress := os.Getenv("CLAMD_ADDRESS")
+	if clamdAddress == "" {
+		clamdAddress = "localhost:3310"
+	}
+
+	mailer := os.Getenv("MAILER")
+	if mailer == "" {
+		mailer = "none"
+	}
+	if mailer != "none" && mailer != "smtp" {
+		return nil, fmt.Errorf("unknown MAILER %q, expected none or smtp", mailer)
+	}
+
+	appURL := os.Getenv("APP_URL")
+	if appURL == "" {
+		appURL = "http://localhost:3000"
+	}
+
+	webhookAttempts := 6
+	if attempts := os.Getenv("WEBHOOK_ATTEMPTS"); attempts != "" {
+		parsed, err := strconv.Atoi(attempts)
+		if err != nil || parsed < 1 || parsed > 20 {
+			return nil, fmt.Errorf("invalid WEBHOOK_ATTEMPTS %q, expected a number from 1 to 20", attempts)
+		}
+		webhookAttempts = parsed
+	}
+
+	webhookBackoff := 30 * time.Second
+	if backoff := os.Getenv("WEBHOOK_BACKOFF"); backoff != "" {
+		parsed, err := time.ParseDuration(backoff)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid WEBHOOK_BACKOFF %q, expected a duration such as 30s", backoff)
+		}
+		webhookBackoff = parsed
+	}
+
+	webhookAllowPrivate := false
+	if allow := os.Getenv("WEBHOOK_ALLOW_PRIVATE"); allow != "" {
+		parsed, err := strconv.ParseBool(allow)
+		if err != nil {
+			return nil, fmt.Errorf("invalid WEBHOOK_ALLOW_PRIVATE %q, expected true or false", allow)
+		}
+		webhookAllowPrivate = parsed
+	}
+
+	var slaDays [4]int
+	for i, stage := range []struct {
+		name     string
+		fallback int
+	}{{"SLA_LECTURER_DAYS", 5}, {"SLA_ADVISOR_DAYS", 3}, {"SLA_HOD_DAYS", 3}, {"SLA_SENATE_DAYS", 5}} {
+		slaDays[i] = stage.fallback
+		if days := os.Getenv(stage.name); days != "" {
+			parsed, err := strconv.Atoi(days)
+			if err != nil || parsed < 0 {
+				return nil, fmt.Errorf("invalid %s %q, expected a number of business days", stage.name, days)
+			}
+			slaDays[i] = parsed
+		}
+	}
+
+	slaLocation := time.Local
+	if zone := os.Getenv("SLA_TIMEZONE"); zone != "" {
+		location, err := time.LoadLocation(zone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SLA_TIMEZONE %q: %w", zone, err)
+		}
+		slaLocation = location
+	}
+
+	slaRemindBefore := 24 * time.Hour
+	if before := os.Getenv("SLA_REMIND_BEFORE"); before != "" {
+		parsed, err := time.ParseDuration(before)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("invalid SLA_REMIND_BEFORE %q, expected a duration such as 24h", before)
+		}
+		slaRemindBefore = parsed
+	}
+
+	slaCheckInterval := 15 * time.Minute
+	if interval := os.Getenv("SLA_CHECK_INTERVAL"); interval != "" {
+		parsed, err := time.ParseDuration(interval)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid SLA_CHECK_INTERVAL %q, expected a duration such as 15m", interval)
+		}
+		slaCheckInterval = parsed
+	}
+
+	var uploadTypes []string
+	for _, t := range strings.Split(os.Getenv("ALLOWED_UPLOAD_TYPES"), ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			uploadTypes = append(uploadTypes, t)
+		}
+	}
+
 	config := &Config{
-		MongoURI: mongoURI,
-		DbName:   dbName,
+		MongoURI:            mongoURI,
+		DbName:              dbName,
+		Store:               store,
+		SeedFile:            os.Getenv("MEMORY_SEED"),
+		AssignmentStrategy:  assignmentStrategy,
+		Storage:             storage,
+		UploadsDir:          uploadsDir,
+		S3Endpoint:          os.Getenv("S3_ENDPOINT"),
+		S3Bucket:            os.Getenv("S3_BUCKET"),
+		S3Region:            os.Getenv("S3_REGION"),
+		S3AccessKey:         os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey:         os.Getenv("S3_SECRET_KEY"),
+		UploadTypes:         uploadTypes,
+		DownloadKey:         downloadKey,
+		DownloadTTL:         downloadTTL,
+		Scanner:             scanner,
+		ClamdAddress:        clamdAddress,
+		Mailer:              mailer,
+		SMTPHost:            os.Getenv("SMTP_HOST"),
+		SMTPPort:            os.Getenv("SMTP_PORT"),
+		SMTPUsername:        os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:        os.Getenv("SMTP_PASSWORD"),
+		MailFrom:            os.Getenv("MAIL_FROM"),
+		AppURL:              appURL,
+		WebhookAttempts:     webhookAttempts,
+		WebhookBackoff:      webhookBackoff,
+		WebhookAllowPrivate: webhookAllowPrivate,
+		SLALecturerDays:     slaDays[0],
+		SLAAdvisorDays:      slaDays[1],
+		SLAHODDays:          slaDays[2],
+		SLASenateDays:       slaDays[3],
+		SLAHolidays:         os.Getenv("SLA_HOLIDAYS"),
+		SLALocation:         slaLocation,
+		SLARemindBefore:     slaRemindBefore,
+		SLACheckInterval:    slaCheckInterval,
 	}
 
 	return config, nil
@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"complaints/cmd/api/models"
+	"complaints/cmd/api/utilities"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var errForbidden = errors.New("you are not allowed to access this resource")
+
+// currentUser returns the user ID and role that middleware.Authenticate stored in the request context
+func currentUser(r *http.Request) (string, string) {
+	userID, _ := r.Context().Value("userID").(string)
+	role, _ := r.Context().Value("role").(string)
+	return userID, role
+}
+
+// requestActor returns the caller in the form recorded in a complaint's history
+func requestActor(r *http.Request) models.Actor {
+	userID, role := currentUser(r)
+	return models.Actor{UserID: userID, Role: role}
+}
+
+// ownID returns the user ID a self-service request is about. The /me routes have no :id and
+// use the caller's own ID; the other routes must name the caller, otherwise 403 is written.
+func ownID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID, _ := currentUser(r)
+	if userID == "" {
+		utilities.ErrorJSON(w, errors.New("unable to get user ID from context"), http.StatusUnauthorized)
+		return "", false
+	}
+
+	id := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	if id != "" && id != userID {
+		utilities.ErrorJSON(w, errForbidden, http.StatusForbidden)
+		return "", false
+	}
+	return userID, true
+}
+
+// canViewComplaint reports whether the caller may see a complaint: students their own,
+// lecturers the ones assigned to them, advisors those of the students they advise, HODs
+// those of their departments' courses, and the Senate all of them
+func canViewComplaint(r *http.Request, complaint models.Complaint) bool {
+	scope, err := callerScope(r)
+	if err != nil {
+		log.Println("Unable to work out what the caller may see:", err)
+		return false
+	}
+	return scope.canView(complaint)
+}
+
+// viewScope is what a user may see, worked out once so many complaints can be checked against it
+type viewScope struct {
+	userID string
+	role   string
+	// courses are the courses of an HOD's departments
+	courses []string
+	// students are the students an advisor advises
+	students []string
+}
+
+// callerScope works out what the caller may see
+func callerScope(r *http.Request) (viewScope, error) {
+	userID, role := currentUser(r)
+	scope := viewScope{userID: userID, role: role}
+
+	var err error
+	switch role {
+	case models.RoleHOD:
+		scope.courses, err = hodCourses(userID)
+	case models.RoleAdvisor:
+		scope.students, err = advisedStudents(userID)
+	}
+	return scope, err
+}
+
+func (s viewScope) canView(complaint models.Complaint) bool {
+	switch s.role {
+	case models.RoleStudent:
+		return complaint.RequestingStudent == s.userID
+	case models.RoleLecturer:
+		return complaint.RespondingLecturer == s.userID
+	case models.RoleHOD:
+		return containsString(s.courses, complaint.CourseConcerned)
+	case models.RoleAdvisor:
+		return containsString(s.students, complaint.RequestingStudent)
+	case models.RoleSenate:
+		return true
+	}
+	return false
+}
+
+// hodCourses returns the codes of every course in the departments the user is HOD of. The
+// result is never nil, so it can be used as a ComplaintQuery.Courses restriction as it is.
+func hodCourses(userID string) ([]string, error) {
+	departments, err := store.Departments.GetDepartmentsByHOD(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	courses := []string{}
+	for _, department := range departments {
+		courses = append(courses, department.Courses...)
+	}
+	return courses, nil
+}
+
+// advisedStudents returns the IDs of every student in the programs and levels the user
+// advises. Like hodCourses, the result is never nil.
+func advisedStudents(userID string) ([]string, error) {
+	advisors, err := store.Advisors.GetAdvisorsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	students := []string{}
+	for _, advisor := range advisors {
+		found, err := store.Students.GetStudentsByProgram(advisor.Program, advisor.Levels)
+		if err != nil {
+			return nil, err
+		}
+		for _, student := range found {
+			students = append(students, student.MatricNo)
+		}
+	}
+	return students, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// authorizedComplaint loads the complaint named by the :id route parameter and checks that the
+// caller may approve or decline it: they must be able to view it, so lecturers only act on the
+// complaints assigned to them, and it must be waiting on their role. It writes the error
+// response and returns false if not.
+func authorizedComplaint(w http.ResponseWriter, r *http.Request) (models.Complaint, bool) {
+	complaint, ok := viewableComplaint(w, r)
+	if !ok {
+		return complaint, false
+	}
+
+	if _, role := currentUser(r); !complaint.AwaitsRole(role) {
+		err := fmt.Errorf("%w: complaint is %q and is not waiting on your review", models.ErrInvalidTransition, complaint.Status)
+		utilities.ErrorJSON(w, err, http.StatusConflict)
+		return models.Complaint{}, false
+	}
+	return complaint, true
+}
+
+// viewableComplaint loads the complaint named by the :id route parameter and checks that the
+// caller may view it, writing the error response and returning false if not
+func viewableComplaint(w http.ResponseWriter, r *http.Request) (models.Complaint, bool) {
+	id := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		utilities.ErrorJSON(w, err)
+		return models.Complaint{}, false
+	}
+
+	complaint, err := store.Complaints.GetComplaintByObjectId(objID)
+	if err != nil {
+		statusErrorJSON(w, err)
+		return models.Complaint{}, false
+	}
+
+	if !canViewComplaint(r, complaint) {
+		utilities.ErrorJSON(w, errForbidden, http.StatusForbidden)
+		return models.Complaint{}, false
+	}
+	return complaint, true
+}
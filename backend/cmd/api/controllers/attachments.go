@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"complaints/cmd/api/models"
+	"complaints/cmd/api/utilities"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetAttachments lists the files attached to a complaint, with signed links to each
+func GetAttachments(w http.ResponseWriter, r *http.Request) {
+	complaint, ok := viewableComplaint(w, r)
+	if !ok {
+		return
+	}
+
+	signComplaint(&complaint)
+	attachments := complaint.Attachments
+	if attachments == nil {
+		attachments = []models.Attachment{}
+	}
+
+	utilities.WriteJSON(w, http.StatusOK, attachments, "attachments")
+}
+
+// AddAttachments attaches the files sent in the "file" fields of a multipart form to a complaint
+// that is still open. Anyone who can see the complaint may add to it.
+func AddAttachments(w http.ResponseWriter, r *http.Request) {
+	id := httprouter.ParamsFromContext(r.Context()).ByName("id")
+
+	complaint, ok := viewableComplaint(w, r)
+	if !ok {
+		return
+	}
+	if complaint.Status.IsFinal() {
+		statusErrorJSON(w, fmt.Errorf("%w: complaint is %q and can no longer have files attached", models.ErrInvalidTransition, complaint.Status))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 10<<20) // 10 MB max
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		http.Error(w, "File too large", http.StatusBadRequest)
+		return
+	}
+	if len(r.MultipartForm.File["file"]) == 0 {
+		utilities.ErrorJSON(w, http.ErrMissingFile)
+		return
+	}
+
+	attachments, err := saveUploads(r, id)
+	if err != nil {
+		uploadErrorJSON(w, err)
+		return
+	}
+
+	if err := store.Complaints.AddAttachments(id, attachments); err != nil {
+		discardUploads(r.Context(), attachments)
+		statusErrorJSON(w, err)
+		return
+	}
+
+	signAttachments(attachments)
+	utilities.WriteJSON(w, http.StatusOK, attachments, "attachments")
+}
+
+// RemoveAttachment removes a file from a complaint that is still open. Only the uploader may
+// remove a file, and files that were the proof of a status change stay with the record.
+func RemoveAttachment(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+	id := params.ByName("id")
+
+	complaint, ok := viewableComplaint(w, r)
+	if !ok {
+		return
+	}
+
+	attachmentID, err := primitive.ObjectIDFromHex(params.ByName("attachment"))
+	if err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+
+	var attachment *models.Attachment
+	for i := range complaint.Attachments {
+		if complaint.Attachments[i].ID == attachmentID {
+			attachment = &complaint.Attachments[i]
+			break
+		}
+	}
+	if attachment == nil {
+		utilities.ErrorJSON(w, models.ErrAttachmentNotFound, http.StatusNotFound)
+		return
+	}
+
+	userID, _ := currentUser(r)
+	if attachment.UploadedBy.UserID != userID {
+		utilities.ErrorJSON(w, errors.New("only the uploader can remove an attachment"), http.StatusForbidden)
+		return
+	}
+	for _, change := range complaint.History {
+		if change.Attachment == attachment.Key {
+			utilities.ErrorJSON(w, errors.New("this file is the proof of a status change and cannot be removed"), http.StatusConflict)
+			return
+		}
+	}
+
+	if err := store.Complaints.RemoveAttachment(id, attachmentID); err != nil {
+		statusErrorJSON(w, err)
+		return
+	}
+	discardUploads(r.Context(), []models.Attachment{*attachment})
+
+	utilities.WriteJSON(w, http.StatusOK, "Attachment removed", "Success")
+}
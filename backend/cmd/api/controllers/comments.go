@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"complaints/cmd/api/models"
+	"complaints/cmd/api/utilities"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxCommentLength bounds the text of a comment, in bytes
+const maxCommentLength = 5000
+
+// commentRoles are the roles that take part in the discussion of a complaint
+var commentRoles = []string{models.RoleStudent, models.RoleLecturer, models.RoleHOD, models.RoleSenate}
+
+// GetComments lists the discussion of a complaint that the caller's role can read, oldest first
+func GetComments(w http.ResponseWriter, r *http.Request) {
+	complaint, ok := viewableComplaint(w, r)
+	if !ok {
+		return
+	}
+
+	_, role := currentUser(r)
+	comments, err := store.Comments.GetComments(complaint.ID, role)
+	if err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+
+	for i := range comments {
+		signAttachments(comments[i].Attachments)
+	}
+	utilities.WriteJSON(w, http.StatusOK, comments, "comments")
+}
+
+// AddComment posts to the discussion of a complaint that is still open. The text comes in the
+// "body" field of a JSON body or multipart form, with files in the form's "file" fields.
+// "visible_to" optionally limits which roles can read the comment, as a JSON list or
+// comma-separated form value; by default every role in the discussion can.
+func AddComment(w http.ResponseWriter, r *http.Request) {
+	id := httprouter.ParamsFromContext(r.Context()).ByName("id")
+
+	complaint, ok := viewableComplaint(w, r)
+	if !ok {
+		return
+	}
+	if complaint.Status.IsFinal() {
+		statusErrorJSON(w, fmt.Errorf("%w: complaint is %q and its discussion is closed", models.ErrInvalidTransition, complaint.Status))
+		return
+	}
+
+	var request struct {
+		Body      string   `json:"body"`
+		VisibleTo []string `json:"visible_to"`
+	}
+	var attachments []models.Attachment
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		r.Body = http.MaxBytesReader(w, r.Body, 10<<20) // 10 MB max
+		if err := r.ParseMultipartForm(10 << 20); err != nil {
+			http.Error(w, "File too large", http.StatusBadRequest)
+			return
+		}
+		request.Body = r.FormValue("body")
+		for _, role := range strings.Split(r.FormValue("visible_to"), ",") {
+			if role = strings.TrimSpace(role); role != "" {
+				request.VisibleTo = append(request.VisibleTo, role)
+			}
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+
+	request.Body = strings.TrimSpace(request.Body)
+	if request.Body == "" {
+		utilities.ErrorJSON(w, errors.New("body is required"))
+		return
+	}
+	if len(request.Body) > maxCommentLength {
+		utilities.ErrorJSON(w, fmt.Errorf("body must be at most %d characters", maxCommentLength))
+		return
+	}
+
+	author := requestActor(r)
+	visibleTo, err := commentVisibility(request.VisibleTo, author.Role)
+	if err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+
+	if r.MultipartForm != nil {
+		attachments, err = saveUploads(r, id)
+		if err != nil {
+			uploadErrorJSON(w, err)
+			return
+		}
+	}
+
+	comment := models.Comment{
+		ID:          primitive.NewObjectID(),
+		ComplaintID: complaint.ID,
+		Author:      author,
+		Body:        request.Body,
+		VisibleTo:   visibleTo,
+		Attachments: attachments,
+		CreatedAt:   time.Now(),
+	}
+	if err := store.Comments.AddComment(comment); err != nil {
+		discardUploads(r.Context(), attachments)
+		statusErrorJSON(w, err)
+		return
+	}
+
+	signAttachments(comment.Attachments)
+	utilities.WriteJSON(w, http.StatusCreated, comment, "comment")
+}
+
+// commentVisibility checks the roles a comment is limited to and adds the author's, so nobody
+// posts a comment they cannot read. No roles means every role in the discussion.
+func commentVisibility(roles []string, authorRole string) ([]string, error) {
+	if len(roles) == 0 {
+		return append([]string(nil), commentRoles...), nil
+	}
+
+	visibleTo := []string{}
+	for _, role := range commentRoles {
+		if role == authorRole || containsString(roles, role) {
+			visibleTo = append(visibleTo, role)
+		}
+	}
+	for _, role := range roles {
+		if !containsString(commentRoles, role) {
+			return nil, fmt.Errorf("unknown role %q in visible_to, expected any of %s", role, strings.Join(commentRoles, ", "))
+		}
+	}
+	return visibleTo, nil
+}
@@ -1,19 +1,20 @@
 package controllers
 
 import (
+	"complaints/cmd/api/assignment"
 	"complaints/cmd/api/models"
+	"complaints/cmd/api/storage"
 	"complaints/cmd/api/utilities"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
+	"io/fs"
 	"log"
-	"math/rand"
 	"net/http"
 	"os"
-	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
@@ -24,14 +25,33 @@ import (
 )
 
 func init() {
+	// the variables may come from the environment instead, as they do in tests
 	err := godotenv.Load()
-	if err != nil {
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
 		log.Fatal("Error loading .env file: ", err)
 	}
 }
 
 var jwtKeyEncoded = os.Getenv("JWTKEY")
 
+// store is the repository every handler reads and writes through
+var store *models.Store
+
+// assigner chooses the responding lecturer for new complaints
+var assigner assignment.Strategy
+
+// SetStore sets the repository used by the handlers
+func SetStore(s *models.Store) {
+	store = s
+}
+
+// SetAssigner sets the strategy NewComplaint uses to pick a lecturer
+func SetAssigner(a assignment.Strategy) {
+	assigner = a
+}
+
+// Register signs up a student. The role in the body is ignored: staff and admin accounts are
+// only created by an admin, through CreateUser.
 func Register(w http.ResponseWriter, r *http.Request) {
 	var user models.User
 	err := json.NewDecoder(r.Body).Decode(&user)
@@ -40,6 +60,27 @@ func Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	user.Role = models.RoleStudent
+	createUser(w, user)
+}
+
+// CreateUser creates an account with any role; it is for admins only
+func CreateUser(w http.ResponseWriter, r *http.Request) {
+	var user models.User
+	err := json.NewDecoder(r.Body).Decode(&user)
+	if err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+
+	if !containsString(models.Roles, user.Role) {
+		utilities.ErrorJSON(w, fmt.Errorf("unknown role %q, expected one of %s", user.Role, strings.Join(models.Roles, ", ")))
+		return
+	}
+	createUser(w, user)
+}
+
+func createUser(w http.ResponseWriter, user models.User) {
 	//hash password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -47,14 +88,16 @@ func Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	//store in the database
+	//store in the database, under a new ID whatever the body said
+	user.ID = primitive.NewObjectID()
 	user.Password = string(hashedPassword)
-	_, err = models.Register(user)
+	_, err = store.Users.Register(user)
 	if err != nil {
 		utilities.ErrorJSON(w, err)
 		return
 	}
 
+	user.Password = ""
 	utilities.WriteJSON(w, http.StatusOK, user, "user")
 }
 
@@ -82,7 +125,7 @@ func Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	//find user by ID
-	user, err := models.GetUserByUserID(credentials.Username)
+	user, err := store.Users.GetUserByUserID(credentials.Username)
 	if err != nil {
 		utilities.WriteJSON(w, http.StatusUnauthorized, bad, "response")
 		return
@@ -149,78 +192,84 @@ func NewComplaint(w http.ResponseWriter, r *http.Request) {
 		fmt.Println(err)
 	}
 
-	file, handler, err := r.FormFile("file")
-	if err != nil {
-		utilities.ErrorJSON(w, err)
+	if len(r.MultipartForm.File["file"]) == 0 {
+		utilities.ErrorJSON(w, http.ErrMissingFile)
 		return
 	}
-	defer file.Close()
 
-	//ensure upload directory exists
-	uploadsDir := "uploads"
-	if _, err := os.Stat(uploadsDir); os.IsNotExist(err) {
-		err = os.Mkdir(uploadsDir, os.ModePerm)
-		if err != nil {
-			utilities.ErrorJSON(w, err)
-			return
-		}
+	studentId, ok := r.Context().Value("userID").(string)
+	if !ok {
+		utilities.ErrorJSON(w, errors.New("unable to get student ID from context"))
+		return
 	}
 
-	//create new fle name and save the file
-	dst, err := os.Create(filepath.Join(uploadsDir, handler.Filename))
+	exists, err := store.Complaints.ComplaintAlreadyExists(studentId, courseConcerned)
 	if err != nil {
 		utilities.ErrorJSON(w, err)
 		return
 	}
-	defer dst.Close()
-
-	if _, err := io.Copy(dst, file); err != nil {
-		utilities.ErrorJSON(w, err)
+	if exists {
+		utilities.ErrorJSON(w, fmt.Errorf("you already have an existing complaint for this course"))
 		return
 	}
 
-	studentId, ok := r.Context().Value("userID").(string)
-	if !ok {
-		utilities.ErrorJSON(w, errors.New("unable to get student ID from context"))
+	// the ID is chosen up front so the proofs can be stored with the complaint they belong to
+	complaintID := primitive.NewObjectID()
+	studentProofs, err := saveUploads(r, complaintID.Hex())
+	if err != nil {
+		uploadErrorJSON(w, err)
 		return
 	}
+	studentProof := studentProofs[0]
 
+	now := time.Now()
 	complaint := models.Complaint{
+		ID:                complaintID,
 		RequestingStudent: studentId,
 		CourseConcerned:   courseConcerned,
 		RequestDetails:    requestDetails,
 		TestScore:         testScore,
-		StudentProof:      fmt.Sprintf("/uploads/%s", handler.Filename),
-		Status:            "Pending",
-		CreatedAt:         time.Now(),
-		UpdatedAt:         time.Now(),
-	}
-	course, err := models.GetCourseByCourseCode(string(complaint.CourseConcerned))
+		StudentProof:      studentProof.Key,
+		Status:            models.StatusPending,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		History: []models.StatusChange{{
+			To:         models.StatusPending,
+			Actor:      requestActor(r),
+			Attachment: studentProof.Key,
+			At:         now,
+		}},
+		Attachments: studentProofs,
+	}
+	course, err := store.Courses.GetCourseByCourseCode(string(complaint.CourseConcerned))
 	if err != nil {
 		utilities.ErrorJSON(w, err)
 		return
 	}
-	if len(course.Lecturers) == 0 {
-		utilities.ErrorJSON(w, errors.New("no lecturers found for the course"))
+	respondingLecturer, reason, err := assigner.Assign(course)
+	if err != nil {
+		utilities.ErrorJSON(w, err)
 		return
 	}
-	respondingLecturer := course.Lecturers[rand.Intn(len(course.Lecturers))]
 
 	complaint.RespondingLecturer = respondingLecturer
-	exists, err := models.ComplaintAlreadyExists(studentId, courseConcerned)
-	if err != nil {
+	complaint.Assignment = &models.Assignment{
+		Strategy:   assigner.Name(),
+		Reason:     reason,
+		AssignedAt: now,
+	}
+	if _, err := store.Complaints.CreateNewComplaint(complaint); err != nil {
+		discardUploads(r.Context(), studentProofs)
 		utilities.ErrorJSON(w, err)
 		return
 	}
-	if !exists {
-		_, err = models.CreateNewComplaint(complaint)
-		if err != nil {
-			utilities.ErrorJSON(w, err)
-			return
-		}
-	} else {
-		utilities.ErrorJSON(w, fmt.Errorf("you already have an existing complaint for this course"))
+	// the store fills in the due date
+	if created, err := store.Complaints.GetComplaintByObjectId(complaint.ID); err == nil {
+		complaint = created
 	}
+
+	signComplaint(&complaint)
+	utilities.WriteJSON(w, http.StatusOK, complaint, "complaint")
 }
 
 func ExtractEmailFromRequest(r *http.Request) string {
@@ -243,47 +292,107 @@ func GetComplaintByObjectID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	complaint, err := models.GetComplaintByObjectId(objID)
+	complaint, err := store.Complaints.GetComplaintByObjectId(objID)
 	if err != nil {
 		fmt.Println("Unable to get complaint")
-		utilities.ErrorJSON(w, err)
+		statusErrorJSON(w, err)
+		return
+	}
+
+	if !canViewComplaint(r, complaint) {
+		utilities.ErrorJSON(w, errForbidden, http.StatusForbidden)
 		return
 	}
 
+	signComplaint(&complaint)
 	utilities.WriteJSON(w, http.StatusOK, complaint, "complaint")
 }
 
-func GetComplaintsByStaffID(w http.ResponseWriter, r *http.Request) {
+// GetComplaintHistory returns the audit trail of status changes for a complaint
+func GetComplaintHistory(w http.ResponseWriter, r *http.Request) {
 	params := httprouter.ParamsFromContext(r.Context())
 	id := params.ByName("id")
 
-	complaints, err := models.GetComplaintsByStaffId(id)
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+
+	complaint, err := store.Complaints.GetComplaintByObjectId(objID)
+	if err != nil {
+		statusErrorJSON(w, err)
+		return
+	}
+
+	if !canViewComplaint(r, complaint) {
+		utilities.ErrorJSON(w, errForbidden, http.StatusForbidden)
+		return
+	}
+
+	history := complaint.History
+	if history == nil {
+		history = []models.StatusChange{}
+	}
+
+	utilities.WriteJSON(w, http.StatusOK, history, "history")
+}
+
+func GetComplaintsByStaffID(w http.ResponseWriter, r *http.Request) {
+	id, ok := ownID(w, r)
+	if !ok {
+		return
+	}
+
+	query, err := complaintQuery(r)
+	if err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+
+	page, err := store.Complaints.GetComplaintsByStaffId(id, query)
 	if err != nil {
 		fmt.Println("Unable to get complaints", err)
 		utilities.ErrorJSON(w, err)
 		return
 	}
 
-	utilities.WriteJSON(w, http.StatusOK, complaints, "complaints")
+	signPage(&page)
+	utilities.WriteJSON(w, http.StatusOK, page, "")
 }
 
 func GetComplaintsByStudentID(w http.ResponseWriter, r *http.Request) {
-	params := httprouter.ParamsFromContext(r.Context())
-	id := params.ByName("id")
+	id, ok := ownID(w, r)
+	if !ok {
+		return
+	}
+
+	query, err := complaintQuery(r)
+	if err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
 
-	complaints, err := models.GetComplaintsByStudentId(id)
+	page, err := store.Complaints.GetComplaintsByStudentId(id, query)
 	if err != nil {
 		fmt.Println("Unable to get complaints", err)
 		utilities.ErrorJSON(w, err)
 		return
 	}
 
-	utilities.WriteJSON(w, http.StatusOK, complaints, "complaints")
+	signPage(&page)
+	utilities.WriteJSON(w, http.StatusOK, page, "")
 }
 
 func ChangeComplaintStatusByLecturer(w http.ResponseWriter, r *http.Request) {
 	params := httprouter.ParamsFromContext(r.Context())
 	id := params.ByName("id")
+
+	complaint, ok := authorizedComplaint(w, r)
+	if !ok {
+		return
+	}
+
 	var updatedComplaint models.Complaint
 
 	r.Body = http.MaxBytesReader(w, r.Body, 10<<20) // 10 MB max
@@ -299,52 +408,64 @@ func ChangeComplaintStatusByLecturer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	file, handler, err := r.FormFile("file")
-	if err != nil {
-		utilities.ErrorJSON(w, err)
+	if len(r.MultipartForm.File["file"]) == 0 {
+		utilities.ErrorJSON(w, http.ErrMissingFile)
 		return
 	}
-	defer file.Close()
 
-	//ensure upload directory exists
-	uploadsDir := "uploads"
-	if _, err := os.Stat(uploadsDir); os.IsNotExist(err) {
-		err = os.Mkdir(uploadsDir, os.ModePerm)
-		if err != nil {
-			utilities.ErrorJSON(w, err)
-			return
-		}
+	lecturerProofs, err := saveUploads(r, id)
+	if err != nil {
+		uploadErrorJSON(w, err)
+		return
 	}
 
-	//create new file name and save the file
-	dst, err := os.Create(filepath.Join(uploadsDir, handler.Filename))
+	updatedComplaint.Reason = reason
+
+	err = store.Complaints.ChangeComplaintStatusLecturer(id, models.StatusApprovedByLecturer, updatedComplaint.Reason, lecturerProofs, requestActor(r))
 	if err != nil {
-		utilities.ErrorJSON(w, err)
+		discardUploads(r.Context(), lecturerProofs)
+		statusErrorJSON(w, err)
 		return
 	}
-	defer dst.Close()
+	escalateWithoutAdvisor(id, complaint)
+	utilities.WriteJSON(w, http.StatusOK, "Status Updated Successfully", "Success")
+}
 
-	if _, err := io.Copy(dst, file); err != nil {
-		utilities.ErrorJSON(w, err)
+// escalateWithoutAdvisor sends a complaint its lecturer approved straight to the HOD queue when
+// no course advisor is assigned to the student's program and level, since no advisor would ever
+// see it. If that fails the complaint still reaches the HOD once its deadline passes.
+func escalateWithoutAdvisor(id string, complaint models.Complaint) {
+	var advisors []models.Advisor
+	student, err := store.Students.GetStudentById(complaint.RequestingStudent)
+	if err == nil {
+		advisors, err = store.Advisors.GetAdvisorsForStudent(student)
+	}
+	if err != nil && !errors.Is(err, models.ErrStudentNotFound) {
+		log.Printf("Unable to look up the advisors for complaint %s: %v", id, err)
+		return
+	}
+	if len(advisors) > 0 {
 		return
 	}
 
-	updatedComplaint.Reason = reason
-
-	lecturerProof := fmt.Sprintf("/uploads/%s", handler.Filename)
-	updatedComplaint.LecturerProof = lecturerProof
-
-	err = models.ChangeComplaintStatusLecturer(id, "Approved By Lecturer", updatedComplaint.Reason, updatedComplaint.LecturerProof)
+	err = store.Complaints.EscalateComplaint(id, models.Escalation{
+		Stage:  models.StatusApprovedByLecturer,
+		Reason: "no course advisor is assigned to the student's program and level",
+		At:     time.Now(),
+	})
 	if err != nil {
-		utilities.ErrorJSON(w, err)
+		log.Printf("Unable to escalate complaint %s without an advisor: %v", id, err)
 	}
-	utilities.WriteJSON(w, http.StatusOK, "Status Updated Successfully", "Success")
 }
 
 func ChangeComplaintStatusByAdvisor(w http.ResponseWriter, r *http.Request) {
 	params := httprouter.ParamsFromContext(r.Context())
 	id := params.ByName("id")
 
+	if _, ok := authorizedComplaint(w, r); !ok {
+		return
+	}
+
 	var updatedComplaint models.Complaint
 	err := json.NewDecoder(r.Body).Decode(&updatedComplaint)
 	if err != nil {
@@ -352,9 +473,10 @@ func ChangeComplaintStatusByAdvisor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = models.ChangeComplaintStatus(id, "Approved By Course Advisor")
+	err = store.Complaints.ChangeComplaintStatus(id, models.StatusApprovedByAdvisor, requestActor(r))
 	if err != nil {
-		utilities.ErrorJSON(w, err)
+		statusErrorJSON(w, err)
+		return
 	}
 
 	utilities.WriteJSON(w, http.StatusOK, "Status Updated Successfully", "Success")
@@ -364,6 +486,10 @@ func ChangeComplaintStatusByHOD(w http.ResponseWriter, r *http.Request) {
 	params := httprouter.ParamsFromContext(r.Context())
 	id := params.ByName("id")
 
+	if _, ok := authorizedComplaint(w, r); !ok {
+		return
+	}
+
 	var updatedComplaint models.Complaint
 	err := json.NewDecoder(r.Body).Decode(&updatedComplaint)
 	if err != nil {
@@ -371,9 +497,10 @@ func ChangeComplaintStatusByHOD(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = models.ChangeComplaintStatus(id, "Approved By HOD")
+	err = store.Complaints.ChangeStatusToByHOD(id, requestActor(r))
 	if err != nil {
-		utilities.ErrorJSON(w, err)
+		statusErrorJSON(w, err)
+		return
 	}
 
 	utilities.WriteJSON(w, http.StatusOK, "Status Updated Successfully", "Success")
@@ -383,6 +510,10 @@ func ChangeComplaintStatusBySenate(w http.ResponseWriter, r *http.Request) {
 	params := httprouter.ParamsFromContext(r.Context())
 	id := params.ByName("id")
 
+	if _, ok := authorizedComplaint(w, r); !ok {
+		return
+	}
+
 	var updatedComplaint models.Complaint
 	err := json.NewDecoder(r.Body).Decode(&updatedComplaint)
 	if err != nil {
@@ -390,9 +521,10 @@ func ChangeComplaintStatusBySenate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = models.ChangeComplaintStatus(id, "Approved By Senate")
+	err = store.Complaints.ChangeComplaintStatus(id, models.StatusApprovedBySenate, requestActor(r))
 	if err != nil {
-		utilities.ErrorJSON(w, err)
+		statusErrorJSON(w, err)
+		return
 	}
 	utilities.WriteJSON(w, http.StatusOK, "Status Updated Successfully", "Success")
 }
@@ -401,7 +533,7 @@ func GetStudentByID(w http.ResponseWriter, r *http.Request) {
 	params := httprouter.ParamsFromContext(r.Context())
 	id := params.ByName("id")
 
-	student, err := models.GetStudentById(id)
+	student, err := store.Students.GetStudentById(id)
 	if err != nil {
 		fmt.Println("Unable to get student")
 		utilities.ErrorJSON(w, err)
@@ -412,10 +544,12 @@ func GetStudentByID(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetCoursesByStudentID(w http.ResponseWriter, r *http.Request) {
-	params := httprouter.ParamsFromContext(r.Context())
-	id := params.ByName("id")
+	id, ok := ownID(w, r)
+	if !ok {
+		return
+	}
 
-	student, err := models.GetStudentById(id)
+	student, err := store.Students.GetStudentById(id)
 	if err != nil {
 		fmt.Println("Unable to get student")
 		utilities.ErrorJSON(w, err)
@@ -428,10 +562,12 @@ func GetCoursesByStudentID(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetCoursesByStaffID(w http.ResponseWriter, r *http.Request) {
-	params := httprouter.ParamsFromContext(r.Context())
-	id := params.ByName("id")
+	id, ok := ownID(w, r)
+	if !ok {
+		return
+	}
 
-	lecturer, err := models.GetStaffById(id)
+	lecturer, err := store.Lecturers.GetStaffById(id)
 	if err != nil {
 		fmt.Println("Unable to get student")
 		utilities.ErrorJSON(w, err)
@@ -443,73 +579,183 @@ func GetCoursesByStaffID(w http.ResponseWriter, r *http.Request) {
 	utilities.WriteJSON(w, http.StatusOK, courses, "courses")
 }
 
+// DeclineRequest declines a complaint with a mandatory reason. Staff send either JSON with a
+// reason or, like the lecturer approval, a multipart form with a reason and an optional file.
 func DeclineRequest(w http.ResponseWriter, r *http.Request) {
 	params := httprouter.ParamsFromContext(r.Context())
 	id := params.ByName("id")
 
-	var updatedComplaint models.Complaint
-	err := json.NewDecoder(r.Body).Decode(&updatedComplaint)
+	if _, ok := authorizedComplaint(w, r); !ok {
+		return
+	}
+
+	var decline struct {
+		Reason string `json:"reason"`
+	}
+	var declineProofs []models.Attachment
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		r.Body = http.MaxBytesReader(w, r.Body, 10<<20) // 10 MB max
+		if err := r.ParseMultipartForm(10 << 20); err != nil {
+			http.Error(w, "File too large", http.StatusBadRequest)
+			return
+		}
+		decline.Reason = r.FormValue("reason")
+
+		var err error
+		declineProofs, err = saveUploads(r, id)
+		if err != nil {
+			uploadErrorJSON(w, err)
+			return
+		}
+	} else {
+		err := json.NewDecoder(r.Body).Decode(&decline)
+		if err != nil {
+			utilities.ErrorJSON(w, err)
+			return
+		}
+	}
+
+	decline.Reason = strings.TrimSpace(decline.Reason)
+	if decline.Reason == "" {
+		discardUploads(r.Context(), declineProofs)
+		utilities.ErrorJSON(w, errors.New("reason is required"))
+		return
+	}
+
+	err := store.Complaints.DeclineComplaint(id, decline.Reason, declineProofs, requestActor(r))
+	if err != nil {
+		discardUploads(r.Context(), declineProofs)
+		statusErrorJSON(w, err)
+		return
+	}
+
+	utilities.WriteJSON(w, http.StatusOK, "Status Updated Successfully", "Success")
+}
+
+// GetComplaintsForAdvisor lists the lecturer-approved complaints of the students the caller advises
+func GetComplaintsForAdvisor(w http.ResponseWriter, r *http.Request) {
+	query, err := complaintQuery(r)
 	if err != nil {
 		utilities.ErrorJSON(w, err)
 		return
 	}
 
-	err = models.ChangeComplaintStatus(id, "Declined")
+	userID, _ := currentUser(r)
+	query.Students, err = advisedStudents(userID)
 	if err != nil {
 		utilities.ErrorJSON(w, err)
+		return
 	}
 
-	utilities.WriteJSON(w, http.StatusOK, "Status Updated Successfully", "Success")
+	page, err := store.Complaints.GetComplaintsByStatus(query, models.StatusApprovedByLecturer)
+	if err != nil {
+		fmt.Println("Unable to get complaints", err)
+		utilities.ErrorJSON(w, err)
+		return
+	}
+
+	signPage(&page)
+	utilities.WriteJSON(w, http.StatusOK, page, "")
 }
 
+// GetComplaintsForHOD lists the complaints of the caller's departments that await the HOD,
+// including those escalated for missing a deadline at an earlier stage
 func GetComplaintsForHOD(w http.ResponseWriter, r *http.Request) {
-	complaints, err := models.GetComplaintsByStatus("Approved By Lecturer")
+	query, err := complaintQuery(r)
+	if err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+
+	userID, _ := currentUser(r)
+	query.Courses, err = hodCourses(userID)
+	if err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+
+	page, err := store.Complaints.GetComplaintsInHODQueue(query)
 	if err != nil {
 		fmt.Println("Unable to get complaints", err)
 		utilities.ErrorJSON(w, err)
 		return
 	}
 
-	utilities.WriteJSON(w, http.StatusOK, complaints, "complaints")
+	signPage(&page)
+	utilities.WriteJSON(w, http.StatusOK, page, "")
 }
 
 func GetComplaintsForSenate(w http.ResponseWriter, r *http.Request) {
-	complaints, err := models.GetComplaintsByStatus("Approved By HOD")
+	query, err := complaintQuery(r)
+	if err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+
+	page, err := store.Complaints.GetComplaintsByStatus(query, models.StatusApprovedByHOD)
 	if err != nil {
 		fmt.Println("Unable to get complaints", err)
 		utilities.ErrorJSON(w, err)
 		return
 	}
 
-	utilities.WriteJSON(w, http.StatusOK, complaints, "complaints")
+	signPage(&page)
+	utilities.WriteJSON(w, http.StatusOK, page, "")
 }
 
 func GetComplaintsByCourseCode(w http.ResponseWriter, r *http.Request) {
-	params := httprouter.ParamsFromContext(r.Context())
-	id := params.ByName("id")
+	id, ok := ownID(w, r)
+	if !ok {
+		return
+	}
 	selectedCourse := r.URL.Query().Get("course")
 
-	complaints, err := models.GetComplaintsByCourseCode(id, selectedCourse)
+	query, err := complaintQuery(r)
+	if err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+
+	page, err := store.Complaints.GetComplaintsByCourseCode(id, selectedCourse, query)
 	if err != nil {
 		fmt.Println("Unable to get complaints", err)
 		utilities.ErrorJSON(w, err)
 		return
 	}
 
-	utilities.WriteJSON(w, http.StatusOK, complaints, "complaints")
+	signPage(&page)
+	utilities.WriteJSON(w, http.StatusOK, page, "")
 }
 
 func GetComplaintByCourseCode(w http.ResponseWriter, r *http.Request) {
-	params := httprouter.ParamsFromContext(r.Context())
-	id := params.ByName("id")
+	id, ok := ownID(w, r)
+	if !ok {
+		return
+	}
 	selectedCourse := r.URL.Query().Get("course")
 
-	complaint, err := models.GetComplaintByCourseCode(id, selectedCourse)
+	complaint, err := store.Complaints.GetComplaintByCourseCode(id, selectedCourse)
 	if err != nil {
 		fmt.Println("Unable to get complaint", err)
 		utilities.ErrorJSON(w, err)
 		return
 	}
+	if complaint != nil {
+		signComplaint(complaint)
+	}
 
 	utilities.WriteJSON(w, http.StatusOK, complaint, "complaint")
 }
+
+// statusErrorJSON reports a failed status change, using 409 for moves the workflow does not allow
+func statusErrorJSON(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidTransition):
+		utilities.ErrorJSON(w, err, http.StatusConflict)
+	case errors.Is(err, models.ErrComplaintNotFound), errors.Is(err, models.ErrAttachmentNotFound), errors.Is(err, storage.ErrNotFound):
+		utilities.ErrorJSON(w, err, http.StatusNotFound)
+	default:
+		utilities.ErrorJSON(w, err)
+	}
+}
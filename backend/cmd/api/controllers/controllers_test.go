@@ -0,0 +1,304 @@
+package controllers
+
+import (
+	"bytes"
+	"complaints/cmd/api/assignment"
+	"complaints/cmd/api/models"
+	"complaints/cmd/api/scanner"
+	"complaints/cmd/api/storage"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// testAPI runs the handlers on a memory store. Requests are authenticated as the user and role
+// they are made with, the way middleware.Authenticate would.
+type testAPI struct {
+	t      *testing.T
+	router *httprouter.Router
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+
+	SetStore(models.NewMemoryStore(models.MemorySeed{
+		Users: []models.User{
+			{UserID: "lec1", Role: models.RoleLecturer},
+			{UserID: "adv1", Role: models.RoleAdvisor},
+			{UserID: "hod1", Role: models.RoleHOD},
+			{UserID: "sen1", Role: models.RoleSenate},
+		},
+		Courses:     []models.Course{{CourseCode: "CSC101", Lecturers: []string{"lec1"}}},
+		Students:    []models.Student{{MatricNo: "stu1", Program: "CS", Level: 100}, {MatricNo: "stu2", Program: "CS", Level: 100}},
+		Lecturers:   []models.Lecturer{{StaffID: "lec1", CoursesTaken: []string{"CSC101"}}},
+		Departments: []models.Department{{Code: "CS", HODs: []string{"hod1"}, Courses: []string{"CSC101"}}},
+		Advisors:    []models.Advisor{{UserID: "adv1", Program: "CS", Levels: []int{100}}},
+	}))
+
+	strategy, err := assignment.New(assignment.LeastOpen, store.Complaints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetAssigner(strategy)
+	files, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetStorage(files)
+	none, err := scanner.New(scanner.None, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetScanner(none)
+	SetDownloadSigning([]byte("test"), time.Minute)
+
+	router := httprouter.New()
+	router.HandlerFunc(http.MethodPost, "/complaint", NewComplaint)
+	router.HandlerFunc(http.MethodGet, "/complaint/:id", GetComplaintByObjectID)
+	router.HandlerFunc(http.MethodPut, "/approved-by-lecturer/:id", ChangeComplaintStatusByLecturer)
+	router.HandlerFunc(http.MethodPut, "/approved-by-advisor/:id", ChangeComplaintStatusByAdvisor)
+	router.HandlerFunc(http.MethodPut, "/approved-by-hod/:id", ChangeComplaintStatusByHOD)
+	router.HandlerFunc(http.MethodPut, "/approved-by-senate/:id", ChangeComplaintStatusBySenate)
+	router.HandlerFunc(http.MethodPut, "/decline/:id", DeclineRequest)
+	router.HandlerFunc(http.MethodPost, "/register", Register)
+	router.HandlerFunc(http.MethodGet, "/me/complaints", GetComplaintsByStudentID)
+	return &testAPI{t: t, router: router}
+}
+
+// do makes a request as userID with role and returns the response
+func (a *testAPI) do(method, path, userID, role, contentType string, body io.Reader) *httptest.ResponseRecorder {
+	a.t.Helper()
+
+	r := httptest.NewRequest(method, path, body)
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	ctx := context.WithValue(r.Context(), "userID", userID)
+	ctx = context.WithValue(ctx, "role", role)
+
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, r.WithContext(ctx))
+	return w
+}
+
+// doJSON makes a request with a JSON body
+func (a *testAPI) doJSON(method, path, userID, role, body string) *httptest.ResponseRecorder {
+	return a.do(method, path, userID, role, "application/json", strings.NewReader(body))
+}
+
+// doForm makes a request with a multipart form of fields and a PNG in its "file" field
+func (a *testAPI) doForm(method, path, userID, role string, fields map[string]string) *httptest.ResponseRecorder {
+	a.t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range fields {
+		form.WriteField(name, value)
+	}
+	file, err := form.CreateFormFile("file", "proof.png")
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	if err := png.Encode(file, image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+		a.t.Fatal(err)
+	}
+	form.Close()
+	return a.do(method, path, userID, role, form.FormDataContentType(), &body)
+}
+
+func (a *testAPI) fileComplaint(student string) string {
+	a.t.Helper()
+
+	w := a.doForm(http.MethodPost, "/complaint", student, models.RoleStudent, map[string]string{
+		"course_concerned": "CSC101",
+		"request_details":  "my score is wrong",
+		"test_score":       "7",
+	})
+	if w.Code != http.StatusOK {
+		a.t.Fatalf("filing a complaint: %d %s", w.Code, w.Body)
+	}
+
+	var response struct {
+		Complaint models.Complaint `json:"complaint"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		a.t.Fatal(err)
+	}
+	if response.Complaint.RespondingLecturer != "lec1" {
+		a.t.Errorf("complaint assigned to %q, want lec1", response.Complaint.RespondingLecturer)
+	}
+	return response.Complaint.ID.Hex()
+}
+
+func TestComplaintWorkflow(t *testing.T) {
+	api := newTestAPI(t)
+	id := api.fileComplaint("stu1")
+
+	steps := []struct {
+		name   string
+		do     func() *httptest.ResponseRecorder
+		status int
+	}{
+		{"another student cannot view it", func() *httptest.ResponseRecorder {
+			return api.doJSON(http.MethodGet, "/complaint/"+id, "stu2", models.RoleStudent, "")
+		}, http.StatusForbidden},
+		{"the HOD cannot decline it before the lecturer", func() *httptest.ResponseRecorder {
+			return api.doJSON(http.MethodPut, "/decline/"+id, "hod1", models.RoleHOD, `{"reason":"no"}`)
+		}, http.StatusConflict},
+		{"the advisor cannot approve it before the lecturer", func() *httptest.ResponseRecorder {
+			return api.doJSON(http.MethodPut, "/approved-by-advisor/"+id, "adv1", models.RoleAdvisor, `{}`)
+		}, http.StatusConflict},
+		{"another lecturer cannot approve it", func() *httptest.ResponseRecorder {
+			return api.doForm(http.MethodPut, "/approved-by-lecturer/"+id, "lec2", models.RoleLecturer, map[string]string{"reason": "ok"})
+		}, http.StatusForbidden},
+		{"the lecturer approves it", func() *httptest.ResponseRecorder {
+			return api.doForm(http.MethodPut, "/approved-by-lecturer/"+id, "lec1", models.RoleLecturer, map[string]string{"reason": "marked wrong"})
+		}, http.StatusOK},
+		{"the lecturer cannot decline it any more", func() *httptest.ResponseRecorder {
+			return api.doJSON(http.MethodPut, "/decline/"+id, "lec1", models.RoleLecturer, `{"reason":"changed my mind"}`)
+		}, http.StatusConflict},
+		{"the advisor approves it", func() *httptest.ResponseRecorder {
+			return api.doJSON(http.MethodPut, "/approved-by-advisor/"+id, "adv1", models.RoleAdvisor, `{}`)
+		}, http.StatusOK},
+		{"the Senate cannot approve it before the HOD", func() *httptest.ResponseRecorder {
+			return api.doJSON(http.MethodPut, "/approved-by-senate/"+id, "sen1", models.RoleSenate, `{}`)
+		}, http.StatusConflict},
+		{"the HOD approves it", func() *httptest.ResponseRecorder {
+			return api.doJSON(http.MethodPut, "/approved-by-hod/"+id, "hod1", models.RoleHOD, `{}`)
+		}, http.StatusOK},
+		{"the Senate approves it", func() *httptest.ResponseRecorder {
+			return api.doJSON(http.MethodPut, "/approved-by-senate/"+id, "sen1", models.RoleSenate, `{}`)
+		}, http.StatusOK},
+		{"nobody can decline it once it is final", func() *httptest.ResponseRecorder {
+			return api.doJSON(http.MethodPut, "/decline/"+id, "sen1", models.RoleSenate, `{"reason":"no"}`)
+		}, http.StatusConflict},
+	}
+	for _, step := range steps {
+		if w := step.do(); w.Code != step.status {
+			t.Fatalf("%s: got %d %s, want %d", step.name, w.Code, w.Body, step.status)
+		}
+	}
+
+	w := api.doJSON(http.MethodGet, "/complaint/"+id, "stu1", models.RoleStudent, "")
+	var response struct {
+		Complaint models.Complaint `json:"complaint"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	complaint := response.Complaint
+	if complaint.Status != models.StatusApprovedBySenate {
+		t.Errorf("status = %q, want %q", complaint.Status, models.StatusApprovedBySenate)
+	}
+	if len(complaint.History) != 5 {
+		t.Errorf("history has %d entries, want 5", len(complaint.History))
+	}
+}
+
+func TestDeclineNeedsReason(t *testing.T) {
+	api := newTestAPI(t)
+	id := api.fileComplaint("stu1")
+
+	if w := api.doJSON(http.MethodPut, "/decline/"+id, "lec1", models.RoleLecturer, `{"reason":"  "}`); w.Code != http.StatusBadRequest {
+		t.Errorf("decline without a reason: got %d %s, want 400", w.Code, w.Body)
+	}
+	if w := api.doJSON(http.MethodPut, "/decline/"+id, "lec1", models.RoleLecturer, `{"reason":"the score is right"}`); w.Code != http.StatusOK {
+		t.Fatalf("decline: got %d %s, want 200", w.Code, w.Body)
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	complaint, err := store.Complaints.GetComplaintByObjectId(objectID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if complaint.Decline == nil || complaint.Decline.Reason != "the score is right" || complaint.Decline.Actor.UserID != "lec1" {
+		t.Errorf("decline = %+v, want the lecturer's reason", complaint.Decline)
+	}
+}
+
+func TestRegisterOnlyCreatesStudents(t *testing.T) {
+	api := newTestAPI(t)
+
+	w := api.doJSON(http.MethodPost, "/register", "", "", `{"user_id":"mallory","role":"C","password":"secret"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("register: got %d %s", w.Code, w.Body)
+	}
+	if strings.Contains(w.Body.String(), "password") {
+		t.Errorf("register response %s has a password field", w.Body)
+	}
+
+	user, err := store.Users.GetUserByUserID("mallory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Role != models.RoleStudent {
+		t.Errorf("registered role = %q, want %q", user.Role, models.RoleStudent)
+	}
+}
+
+func TestComplaintListDateRangeIsInclusive(t *testing.T) {
+	api := newTestAPI(t)
+	id := api.fileComplaint("stu1")
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	complaint, err := store.Complaints.GetComplaintByObjectId(objectID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := complaint.CreatedAt
+
+	tests := []struct {
+		name     string
+		from, to string
+		want     int
+	}{
+		{"to on the creation time", "", created.Format(time.RFC3339Nano), 1},
+		{"to just before it", "", created.Add(-time.Nanosecond).Format(time.RFC3339Nano), 0},
+		{"from on the creation time", created.Format(time.RFC3339Nano), "", 1},
+		{"from just after it", created.Add(time.Nanosecond).Format(time.RFC3339Nano), "", 0},
+		{"from and to on the creation time", created.Format(time.RFC3339Nano), created.Format(time.RFC3339Nano), 1},
+		{"to on the day it was filed", "", created.UTC().Format("2006-01-02"), 1},
+		{"from on the day it was filed", created.UTC().Format("2006-01-02"), "", 1},
+		{"to on the day before", "", created.UTC().AddDate(0, 0, -1).Format("2006-01-02"), 0},
+	}
+
+	for _, tt := range tests {
+		values := url.Values{}
+		if tt.from != "" {
+			values.Set("from", tt.from)
+		}
+		if tt.to != "" {
+			values.Set("to", tt.to)
+		}
+		w := api.doJSON(http.MethodGet, "/me/complaints?"+values.Encode(), "stu1", models.RoleStudent, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: got %d %s", tt.name, w.Code, w.Body)
+		}
+
+		var page models.ComplaintPage
+		if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Complaints) != tt.want || page.Total != int64(tt.want) {
+			t.Errorf("%s: listed %d complaints (total %d), want %d", tt.name, len(page.Complaints), page.Total, tt.want)
+		}
+	}
+}
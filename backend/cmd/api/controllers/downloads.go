@@ -0,0 +1,236 @@
+package controllers
+
+import (
+	"complaints/cmd/api/models"
+	"complaints/cmd/api/storage"
+	"complaints/cmd/api/utilities"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var errInvalidSignature = errors.New("download link is invalid or has expired")
+
+// downloadKey signs the links GetUpload accepts and downloadTTL is how long they stay valid
+var (
+	downloadKey []byte
+	downloadTTL = 5 * time.Minute
+)
+
+// SetDownloadSigning sets the key and lifetime of signed download links
+func SetDownloadSigning(key []byte, ttl time.Duration) {
+	downloadKey = key
+	downloadTTL = ttl
+}
+
+// signedURL returns a link to the stored file that works without a token until it expires, so
+// it can be used where headers cannot be sent, such as an <img> tag
+func signedURL(key string) string {
+	expires := strconv.FormatInt(time.Now().Add(downloadTTL).Unix(), 10)
+	query := url.Values{"expires": {expires}, "signature": {downloadSignature(key, expires)}}
+	return "/uploads/" + key + "?" + query.Encode()
+}
+
+func downloadSignature(key, expires string) string {
+	mac := hmac.New(sha256.New, downloadKey)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validSignature checks the expiry and signature of a signed link to key
+func validSignature(key string, query url.Values) bool {
+	expires := query.Get("expires")
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return false
+	}
+	signature, err := hex.DecodeString(query.Get("signature"))
+	if err != nil {
+		return false
+	}
+	expected, _ := hex.DecodeString(downloadSignature(key, expires))
+	return hmac.Equal(signature, expected)
+}
+
+// signComplaint fills in signed links to the files of a complaint the caller may view
+func signComplaint(complaint *models.Complaint) {
+	if complaint.StudentProof != "" {
+		complaint.StudentProofURL = signedURL(complaint.StudentProof)
+	}
+	if complaint.LecturerProof != "" {
+		complaint.LecturerProofURL = signedURL(complaint.LecturerProof)
+	}
+	signAttachments(complaint.Attachments)
+	for _, attachment := range complaint.Attachments {
+		if attachment.Key == complaint.StudentProof && attachment.ThumbnailURL != "" {
+			complaint.ThumbnailURL = attachment.ThumbnailURL
+		}
+	}
+}
+
+// signPage signs every complaint on a page of results
+func signPage(page *models.ComplaintPage) {
+	for i := range page.Complaints {
+		signComplaint(&page.Complaints[i])
+	}
+}
+
+func signAttachments(attachments []models.Attachment) {
+	for i := range attachments {
+		attachments[i].URL = signedURL(attachments[i].Key)
+		if attachments[i].ThumbnailKey != "" {
+			attachments[i].ThumbnailURL = signedURL(attachments[i].ThumbnailKey)
+		}
+	}
+}
+
+// GetUpload serves a stored file through a signed link made by signedURL
+func GetUpload(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(httprouter.ParamsFromContext(r.Context()).ByName("key"), "/")
+	if !validSignature(key, r.URL.Query()) {
+		utilities.ErrorJSON(w, errInvalidSignature, http.StatusForbidden)
+		return
+	}
+
+	serveFile(w, r, key)
+}
+
+// DownloadFile serves a stored file to an authenticated caller who may view the complaint it
+// belongs to, or the comment it was posted with
+func DownloadFile(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(httprouter.ParamsFromContext(r.Context()).ByName("key"), "/")
+	complaint, err := complaintForKey(key)
+	if err != nil {
+		statusErrorJSON(w, err)
+		return
+	}
+
+	if !canViewComplaint(r, complaint) {
+		utilities.ErrorJSON(w, errForbidden, http.StatusForbidden)
+		return
+	}
+
+	found := referencesFile(complaint, key)
+	if !found {
+		_, role := currentUser(r)
+		found, err = commentReferencesFile(complaint, role, key)
+		if err != nil {
+			utilities.ErrorJSON(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+	if !found {
+		statusErrorJSON(w, storage.ErrNotFound)
+		return
+	}
+
+	serveFile(w, r, key)
+}
+
+// complaintForKey returns the complaint a stored file belongs to; keys start with the
+// complaint's ID
+func complaintForKey(key string) (models.Complaint, error) {
+	id, _, _ := strings.Cut(key, "/")
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil || !storage.ValidKey(key) {
+		return models.Complaint{}, models.ErrComplaintNotFound
+	}
+
+	return store.Complaints.GetComplaintByObjectId(objID)
+}
+
+// referencesFile reports whether a complaint itself still refers to a stored file
+func referencesFile(complaint models.Complaint, key string) bool {
+	if complaint.StudentProof == key || complaint.LecturerProof == key {
+		return true
+	}
+	if complaint.Decline != nil && complaint.Decline.Proof == key {
+		return true
+	}
+	return attachmentsReference(complaint.Attachments, key)
+}
+
+// commentReferencesFile reports whether a file was posted with one of the comments on a complaint
+// that role can read
+func commentReferencesFile(complaint models.Complaint, role, key string) (bool, error) {
+	comments, err := store.Comments.GetComments(complaint.ID, role)
+	if err != nil {
+		return false, err
+	}
+	for _, comment := range comments {
+		if attachmentsReference(comment.Attachments, key) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func attachmentsReference(attachments []models.Attachment, key string) bool {
+	for _, attachment := range attachments {
+		if attachment.Key == key || attachment.ThumbnailKey == key {
+			return true
+		}
+	}
+	return false
+}
+
+// typeForExtension returns the type of files saved with ext, for storage that does not keep it
+func typeForExtension(ext string) string {
+	for contentType, e := range uploadExtensions {
+		if e == ext {
+			return contentType
+		}
+	}
+	return mime.TypeByExtension(ext)
+}
+
+// serveFile copies a stored file to the response. Quarantined files are never served.
+func serveFile(w http.ResponseWriter, r *http.Request, key string) {
+	if !storage.ValidKey(key) || isQuarantined(key) {
+		utilities.ErrorJSON(w, storage.ErrNotFound, http.StatusNotFound)
+		return
+	}
+
+	object, err := files.Get(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			utilities.ErrorJSON(w, err, http.StatusNotFound)
+			return
+		}
+		log.Println("Unable to read upload:", err)
+		utilities.ErrorJSON(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer object.Close()
+
+	contentType := object.ContentType
+	if contentType == "" {
+		contentType = typeForExtension(path.Ext(key))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, no-store")
+	if object.Size > 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(object.Size, 10))
+	}
+
+	if _, err := io.Copy(w, object); err != nil {
+		log.Println("Unable to send upload:", err)
+	}
+}
@@ -0,0 +1,218 @@
+package controllers
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var errMalformedHEIF = errors.New("malformed HEIF image")
+
+// heifBox is an ISO base media box: a 4-character type and the payload after its header
+type heifBox struct {
+	kind    string
+	payload []byte
+	// offset is where the payload starts in the file
+	offset int
+}
+
+// heifBoxes splits data into boxes. offset is the position of data in the file.
+func heifBoxes(data []byte, offset int) ([]heifBox, error) {
+	var boxes []heifBox
+	for i := 0; i < len(data); {
+		if i+8 > len(data) {
+			return nil, errMalformedHEIF
+		}
+		size, header := int(binary.BigEndian.Uint32(data[i:])), 8
+		kind := string(data[i+4 : i+8])
+		switch size {
+		case 0:
+			size = len(data) - i
+		case 1:
+			if i+16 > len(data) {
+				return nil, errMalformedHEIF
+			}
+			size, header = int(binary.BigEndian.Uint64(data[i+8:])), 16
+		}
+		if size < header || i+size > len(data) || size < 0 {
+			return nil, errMalformedHEIF
+		}
+		boxes = append(boxes, heifBox{kind: kind, payload: data[i+header : i+size], offset: offset + i + header})
+		i += size
+	}
+	return boxes, nil
+}
+
+// stripHEIFMetadata overwrites the Exif and XMP items of a HEIF file with zeros. The items stay
+// in place so every offset in the file remains valid; only their content is gone.
+func stripHEIFMetadata(data []byte) error {
+	boxes, err := heifBoxes(data, 0)
+	if err != nil {
+		return err
+	}
+
+	for _, box := range boxes {
+		if box.kind != "meta" {
+			continue
+		}
+		if len(box.payload) < 4 {
+			return errMalformedHEIF
+		}
+		children, err := heifBoxes(box.payload[4:], box.offset+4)
+		if err != nil {
+			return err
+		}
+
+		var metadata map[uint32]bool
+		for _, child := range children {
+			if child.kind == "iinf" {
+				if metadata, err = metadataItems(child.payload); err != nil {
+					return err
+				}
+			}
+		}
+		if len(metadata) == 0 {
+			continue
+		}
+		for _, child := range children {
+			if child.kind == "iloc" {
+				if err := blankItems(data, child.payload, metadata); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// metadataItems returns the IDs of the Exif and XMP items listed in an iinf box
+func metadataItems(iinf []byte) (map[uint32]bool, error) {
+	if len(iinf) < 6 {
+		return nil, errMalformedHEIF
+	}
+	start := 6
+	if iinf[0] != 0 {
+		start = 8
+	}
+	if start > len(iinf) {
+		return nil, errMalformedHEIF
+	}
+	entries, err := heifBoxes(iinf[start:], 0)
+	if err != nil {
+		return nil, err
+	}
+
+	items := map[uint32]bool{}
+	for _, entry := range entries {
+		infe := entry.payload
+		if entry.kind != "infe" || len(infe) < 4 || infe[0] < 2 {
+			continue
+		}
+		var id uint32
+		var rest []byte
+		if infe[0] == 2 && len(infe) >= 12 {
+			id, rest = uint32(binary.BigEndian.Uint16(infe[4:])), infe[8:]
+		} else if infe[0] == 3 && len(infe) >= 14 {
+			id, rest = binary.BigEndian.Uint32(infe[4:]), infe[10:]
+		} else {
+			continue
+		}
+		kind := string(rest[:4])
+		if kind == "Exif" {
+			items[id] = true
+		}
+		if kind == "mime" {
+			// the item name and content type follow as NUL-terminated strings
+			fields := splitNUL(rest[4:])
+			if len(fields) >= 2 && fields[1] == "application/rdf+xml" {
+				items[id] = true
+			}
+		}
+	}
+	return items, nil
+}
+
+func splitNUL(b []byte) []string {
+	var fields []string
+	for len(b) > 0 {
+		end := 0
+		for end < len(b) && b[end] != 0 {
+			end++
+		}
+		fields = append(fields, string(b[:end]))
+		if end == len(b) {
+			break
+		}
+		b = b[end+1:]
+	}
+	return fields
+}
+
+// blankItems zeroes the file extents that an iloc box gives for the listed items. Items kept
+// inside the meta box rather than at file offsets are left alone.
+func blankItems(data, iloc []byte, items map[uint32]bool) error {
+	r := heifReader{b: iloc}
+	version := r.uint(1)
+	r.uint(3)
+	sizes := r.uint(2)
+	offsetSize, lengthSize := int(sizes>>12&0xF), int(sizes>>8&0xF)
+	baseOffsetSize, indexSize := int(sizes>>4&0xF), int(sizes&0xF)
+	if version == 0 {
+		indexSize = 0
+	}
+
+	count := r.uint(2)
+	if version == 2 {
+		count = r.uint(4)
+	}
+	for n := uint64(0); n < count && r.err == nil; n++ {
+		id := r.uint(2)
+		if version == 2 {
+			id = r.uint(4)
+		}
+		method := uint64(0)
+		if version == 1 || version == 2 {
+			method = r.uint(2) & 0xF
+		}
+		r.uint(2)
+		base := r.uint(baseOffsetSize)
+		extents := r.uint(2)
+		for e := uint64(0); e < extents && r.err == nil; e++ {
+			r.uint(indexSize)
+			offset := base + r.uint(offsetSize)
+			length := r.uint(lengthSize)
+			if !items[uint32(id)] || method != 0 {
+				continue
+			}
+			if length == 0 {
+				length = uint64(len(data)) - offset
+			}
+			if offset > uint64(len(data)) || length > uint64(len(data))-offset {
+				return errMalformedHEIF
+			}
+			clear(data[offset : offset+length])
+		}
+	}
+	return r.err
+}
+
+// heifReader reads big-endian integers of varying widths, remembering the first overrun
+type heifReader struct {
+	b   []byte
+	err error
+}
+
+func (r *heifReader) uint(size int) uint64 {
+	if r.err != nil || size == 0 {
+		return 0
+	}
+	if size > len(r.b) || size > 8 {
+		r.err = errMalformedHEIF
+		return 0
+	}
+	var v uint64
+	for _, c := range r.b[:size] {
+		v = v<<8 | uint64(c)
+	}
+	r.b = r.b[size:]
+	return v
+}
@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+const (
+	// maxImagePixels bounds the images that are decoded, so a small file cannot expand into
+	// gigabytes of pixels
+	maxImagePixels = 50_000_000
+	// thumbnailSize is the longest side of generated thumbnails
+	thumbnailSize = 320
+	// thumbnailSuffix replaces the extension of an image's key to name its thumbnail
+	thumbnailSuffix = ".thumb.jpg"
+)
+
+// cleanImage removes the metadata of an uploaded image, such as the GPS position phones record,
+// and makes a JPEG thumbnail of it. PNG and JPEG images are decoded and encoded again, which
+// keeps only the pixels; JPEG orientation is applied first so photos stay upright. HEIC cannot
+// be decoded here, so its Exif and XMP items are blanked in place and it gets no thumbnail.
+// Files of other types are returned as nil.
+func cleanImage(contentType string, file io.Reader) (cleaned, thumbnail []byte, err error) {
+	switch contentType {
+	case "image/png", "image/jpeg":
+	case "image/heic", "image/heif":
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := stripHEIFMetadata(data); err != nil {
+			return nil, nil, err
+		}
+		return data, nil, nil
+	default:
+		return nil, nil, nil
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read image: %w", err)
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, nil, errors.New("image dimensions are too large")
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read image: %w", err)
+	}
+
+	var out bytes.Buffer
+	if contentType == "image/png" {
+		err = png.Encode(&out, img)
+	} else {
+		if orientation := jpegOrientation(data); orientation > 1 {
+			img = orient(toRGBA(img), orientation)
+		}
+		err = jpeg.Encode(&out, img, &jpeg.Options{Quality: 90})
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var thumb bytes.Buffer
+	if err := jpeg.Encode(&thumb, shrink(toRGBA(img), thumbnailSize), &jpeg.Options{Quality: 80}); err != nil {
+		return nil, nil, err
+	}
+	return out.Bytes(), thumb.Bytes(), nil
+}
+
+// toRGBA copies img onto a white background, so transparent areas stay white once the alpha
+// channel is dropped by JPEG
+func toRGBA(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Over)
+	return rgba
+}
+
+// shrink scales img down to fit in a size by size square by averaging the pixels each
+// thumbnail pixel covers. Images that already fit are returned as they are.
+func shrink(img *image.RGBA, size int) *image.RGBA {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	if w <= size && h <= size {
+		return img
+	}
+	tw, th := size, h*size/w
+	if h > w {
+		tw, th = w*size/h, size
+	}
+	tw, th = max(tw, 1), max(th, 1)
+
+	thumb := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for ty := 0; ty < th; ty++ {
+		y0, y1 := ty*h/th, max((ty+1)*h/th, ty*h/th+1)
+		for tx := 0; tx < tw; tx++ {
+			x0, x1 := tx*w/tw, max((tx+1)*w/tw, tx*w/tw+1)
+			var sum [4]int
+			for y := y0; y < y1; y++ {
+				row := img.Pix[y*img.Stride+x0*4 : y*img.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += int(row[i])
+					sum[1] += int(row[i+1])
+					sum[2] += int(row[i+2])
+					sum[3] += int(row[i+3])
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			i := ty*thumb.Stride + tx*4
+			for c := 0; c < 4; c++ {
+				thumb.Pix[i+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+	return thumb
+}
+
+// orient turns img the way the Exif orientation tag says it should be displayed
+func orient(img *image.RGBA, orientation int) *image.RGBA {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			sx, sy := x, y
+			switch orientation {
+			case 2:
+				sx = w - 1 - x
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sy = h - 1 - y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			copy(out.Pix[y*out.Stride+x*4:y*out.Stride+x*4+4], img.Pix[sy*img.Stride+sx*4:sy*img.Stride+sx*4+4])
+		}
+	}
+	return out
+}
+
+// jpegOrientation reads the orientation tag from the Exif segment of a JPEG, returning 1, the
+// upright orientation, when there is none
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data) && data[i] == 0xFF; {
+		marker := data[i+1]
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if marker == 0xDA || length < 2 || i+2+length > len(data) {
+			break
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// exifOrientation finds tag 0x0112 in the first IFD of a TIFF-structured Exif block
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if orientation := int(order.Uint16(tiff[entry+8:])); orientation >= 1 && orientation <= 8 {
+				return orientation
+			}
+			break
+		}
+	}
+	return 1
+}
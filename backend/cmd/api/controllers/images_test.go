@@ -0,0 +1,245 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"testing"
+)
+
+// box returns an ISO base media box of kind holding the concatenated payload
+func box(kind string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	b := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	return append(append(b, kind...), body...)
+}
+
+// fullBox returns a box whose payload starts with a version and three bytes of flags
+func fullBox(kind string, version byte, payload ...[]byte) []byte {
+	return box(kind, append([][]byte{{version, 0, 0, 0}}, payload...)...)
+}
+
+func u16(v uint16) []byte { return binary.BigEndian.AppendUint16(nil, v) }
+func u32(v uint32) []byte { return binary.BigEndian.AppendUint32(nil, v) }
+
+// heifItem is an item of a test HEIF file, stored in its mdat box
+type heifItem struct {
+	id       uint16
+	kind     string
+	mimeType string
+	data     []byte
+}
+
+// buildHEIF returns a HEIF file with items in its mdat box, and where each item's data starts
+func buildHEIF(items []heifItem) ([]byte, map[uint16]int) {
+	build := func(mdatStart int) []byte {
+		var infes, locations [][]byte
+		offset := mdatStart
+		for _, item := range items {
+			entry := [][]byte{u16(item.id), u16(0), []byte(item.kind), {0}}
+			if item.kind == "mime" {
+				entry = append(entry, []byte(item.mimeType), []byte{0})
+			}
+			infes = append(infes, fullBox("infe", 2, entry...))
+			// data reference 0, one extent of a 4-byte offset and a 4-byte length
+			locations = append(locations, u16(item.id), u16(0), u16(1), u32(uint32(offset)), u32(uint32(len(item.data))))
+			offset += len(item.data)
+		}
+		iinf := fullBox("iinf", 0, append([][]byte{u16(uint16(len(items)))}, infes...)...)
+		iloc := fullBox("iloc", 0, append([][]byte{{0x44, 0x00}, u16(uint16(len(items)))}, locations...)...)
+		meta := fullBox("meta", 0, fullBox("hdlr", 0, make([]byte, 4), []byte("pict"), make([]byte, 13)), iinf, iloc)
+		ftyp := box("ftyp", []byte("heic"), u32(0), []byte("mif1heic"))
+
+		var data [][]byte
+		for _, item := range items {
+			data = append(data, item.data)
+		}
+		return bytes.Join([][]byte{ftyp, meta, box("mdat", data...)}, nil)
+	}
+
+	// the layout does not depend on the offsets, so a first pass finds where mdat starts
+	file := build(0)
+	mdatStart := len(file) - 8
+	for _, item := range items {
+		mdatStart -= len(item.data)
+	}
+	file = build(mdatStart)
+
+	starts := map[uint16]int{}
+	for _, item := range items {
+		starts[item.id] = mdatStart
+		mdatStart += len(item.data)
+	}
+	return file, starts
+}
+
+func TestStripHEIFMetadata(t *testing.T) {
+	items := []heifItem{
+		{id: 1, kind: "hvc1", data: []byte("compressed image data")},
+		{id: 2, kind: "Exif", data: []byte("\x00\x00\x00\x06Exif\x00\x00MM GPS 6.5244N 3.3792E")},
+		{id: 3, kind: "mime", mimeType: "application/rdf+xml", data: []byte("<x:xmpmeta>exif:GPSLatitude</x:xmpmeta>")},
+		{id: 4, kind: "mime", mimeType: "text/plain", data: []byte("kept")},
+	}
+	file, starts := buildHEIF(items)
+
+	want := bytes.Clone(file)
+	for _, item := range items[1:3] {
+		clear(want[starts[item.id] : starts[item.id]+len(item.data)])
+	}
+
+	if err := stripHEIFMetadata(file); err != nil {
+		t.Fatalf("stripHEIFMetadata() error = %v", err)
+	}
+	if !bytes.Equal(file, want) {
+		t.Errorf("stripHEIFMetadata() =\n%q\nwant\n%q", file, want)
+	}
+	if bytes.Contains(file, []byte("GPS")) {
+		t.Error("stripped file still contains the location")
+	}
+}
+
+func TestStripHEIFMetadataRejectsMalformedFiles(t *testing.T) {
+	file, _ := buildHEIF([]heifItem{{id: 1, kind: "Exif", data: []byte("Exif\x00\x00")}})
+	withoutMeta := box("ftyp", []byte("heic"))
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"no meta box", withoutMeta, nil},
+		{"empty", nil, nil},
+		{"box longer than the file", file[:len(file)-1], errMalformedHEIF},
+		{"truncated box header", append(bytes.Clone(withoutMeta), 0, 0, 0), errMalformedHEIF},
+		{"box shorter than its header", append(bytes.Clone(withoutMeta), 0, 0, 0, 4, 'f', 'r', 'e', 'e'), errMalformedHEIF},
+		{"meta box without a version", box("meta"), errMalformedHEIF},
+		{"extent past the end of the file", extentPastEnd(), errMalformedHEIF},
+	}
+
+	for _, tt := range tests {
+		before := bytes.Clone(tt.data)
+		err := stripHEIFMetadata(tt.data)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: stripHEIFMetadata() error = %v, want %v", tt.name, err, tt.err)
+		}
+		if tt.err == nil && !bytes.Equal(tt.data, before) {
+			t.Errorf("%s: stripHEIFMetadata() changed a file without metadata", tt.name)
+		}
+	}
+}
+
+// extentPastEnd returns a HEIF file whose Exif item is said to run past the end of the file
+func extentPastEnd() []byte {
+	iinf := fullBox("iinf", 0, u16(1), fullBox("infe", 2, u16(1), u16(0), []byte("Exif"), []byte{0}))
+	iloc := fullBox("iloc", 0, []byte{0x44, 0x00}, u16(1), u16(1), u16(0), u16(1), u32(0), u32(1<<20))
+	return fullBox("meta", 0, iinf, iloc)
+}
+
+// exifSegment returns a JPEG APP1 segment holding an Exif block with the orientation tag, in
+// the byte order named by order, followed by extra
+func exifSegment(order string, orientation uint16, extra string) []byte {
+	var byteOrder binary.AppendByteOrder = binary.BigEndian
+	if order == "II" {
+		byteOrder = binary.LittleEndian
+	}
+
+	tiff := []byte(order)
+	tiff = byteOrder.AppendUint16(tiff, 42)
+	tiff = byteOrder.AppendUint32(tiff, 8)
+	tiff = byteOrder.AppendUint16(tiff, 2)
+	// ImageDescription, then Orientation as one SHORT
+	tiff = byteOrder.AppendUint16(tiff, 0x010E)
+	tiff = byteOrder.AppendUint16(tiff, 2)
+	tiff = byteOrder.AppendUint32(tiff, 1)
+	tiff = byteOrder.AppendUint32(tiff, 0)
+	tiff = byteOrder.AppendUint16(tiff, 0x0112)
+	tiff = byteOrder.AppendUint16(tiff, 3)
+	tiff = byteOrder.AppendUint32(tiff, 1)
+	tiff = byteOrder.AppendUint16(tiff, orientation)
+	tiff = byteOrder.AppendUint16(tiff, 0)
+	tiff = byteOrder.AppendUint32(tiff, 0)
+	tiff = append(tiff, extra...)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	return append([]byte{0xFF, 0xE1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}, payload...)
+}
+
+// withSegments inserts segments into a JPEG right after its start of image marker
+func withSegments(jpegData []byte, segments ...[]byte) []byte {
+	return bytes.Join(append(append([][]byte{jpegData[:2]}, segments...), jpegData[2:]), nil)
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var b bytes.Buffer
+	if err := jpeg.Encode(&b, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	plain := encodeJPEG(t, image.NewGray(image.Rect(0, 0, 8, 8)))
+	jfif := []byte{0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0, 1, 1, 0, 0, 1, 0, 1, 0, 0}
+
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"no Exif", plain, 1},
+		{"big-endian", withSegments(plain, exifSegment("MM", 6, "")), 6},
+		{"little-endian", withSegments(plain, exifSegment("II", 8, "")), 8},
+		{"after a JFIF segment", withSegments(plain, jfif, exifSegment("MM", 3, "")), 3},
+		{"out of range", withSegments(plain, exifSegment("II", 9, "")), 1},
+		{"unknown byte order", withSegments(plain, bytes.Replace(exifSegment("MM", 6, ""), []byte("MM"), []byte("XX"), 1)), 1},
+		{"truncated segment", withSegments(plain, exifSegment("MM", 6, ""))[:30], 1},
+		{"not a JPEG", []byte("\x89PNG\r\n\x1a\n"), 1},
+		{"empty", nil, 1},
+	}
+
+	for _, tt := range tests {
+		if got := jpegOrientation(tt.data); got != tt.want {
+			t.Errorf("%s: jpegOrientation() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCleanImageAppliesOrientationAndDropsExif(t *testing.T) {
+	// a 16x24 portrait, blue with a red square in the top left corner
+	img := image.NewRGBA(image.Rect(0, 0, 16, 24))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{0, 0, 255, 255}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 8, 8), image.NewUniform(color.RGBA{255, 0, 0, 255}), image.Point{}, draw.Src)
+	// orientation 6 means the camera was turned, so the image is shown turned a quarter clockwise
+	upload := withSegments(encodeJPEG(t, img), exifSegment("MM", 6, "GPS 6.5244N 3.3792E"))
+
+	cleaned, thumbnail, err := cleanImage("image/jpeg", bytes.NewReader(upload))
+	if err != nil {
+		t.Fatalf("cleanImage() error = %v", err)
+	}
+	if bytes.Contains(cleaned, []byte("Exif")) || bytes.Contains(cleaned, []byte("GPS")) {
+		t.Error("cleaned image still has its Exif data")
+	}
+	if len(thumbnail) == 0 {
+		t.Error("cleanImage() made no thumbnail")
+	}
+
+	out, err := jpeg.Decode(bytes.NewReader(cleaned))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := out.Bounds().Size(); size != image.Pt(24, 16) {
+		t.Fatalf("cleaned image is %v, want 24x16", size)
+	}
+	if r, _, b, _ := out.At(20, 4).RGBA(); r < b {
+		t.Errorf("top right pixel is not red after turning the image")
+	}
+	if r, _, b, _ := out.At(4, 4).RGBA(); r > b {
+		t.Errorf("top left pixel is not blue after turning the image")
+	}
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"complaints/cmd/api/utilities"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultNotifications = 20
+	maxNotifications     = 100
+)
+
+// GetNotifications lists the caller's newest notifications. ?unread=true leaves out those
+// already read and ?limit sets how many are returned.
+func GetNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, _ := currentUser(r)
+	values := r.URL.Query()
+
+	limit := defaultNotifications
+	if l := values.Get("limit"); l != "" {
+		var err error
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 || limit > maxNotifications {
+			utilities.ErrorJSON(w, fmt.Errorf("limit must be between 1 and %d", maxNotifications))
+			return
+		}
+	}
+
+	notifications, err := store.Notifications.GetNotifications(userID, values.Get("unread") == "true", limit)
+	if err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+
+	utilities.WriteJSON(w, http.StatusOK, notifications, "notifications")
+}
+
+// CountUnreadNotifications returns how many of the caller's notifications are unread, for a badge
+func CountUnreadNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, _ := currentUser(r)
+
+	count, err := store.Notifications.CountUnreadNotifications(userID)
+	if err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+
+	utilities.WriteJSON(w, http.StatusOK, count, "unread")
+}
+
+// MarkNotificationsRead marks the notifications listed in the body's "ids" as read, or all of
+// the caller's notifications when there are none
+func MarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	userID, _ := currentUser(r)
+
+	var request struct {
+		IDs []primitive.ObjectID `json:"ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+
+	count, err := store.Notifications.MarkNotificationsRead(userID, request.IDs)
+	if err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+
+	utilities.WriteJSON(w, http.StatusOK, count, "marked")
+}
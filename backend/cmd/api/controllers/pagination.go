@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"complaints/cmd/api/models"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+)
+
+// complaintQuery reads paging, filtering and sorting for a complaint list from the URL:
+// page, per_page, status (repeated or comma separated), course, from and to (inclusive, as
+// YYYY-MM-DD or RFC 3339), sort and order (asc or desc)
+func complaintQuery(r *http.Request) (models.ComplaintQuery, error) {
+	values := r.URL.Query()
+	query := models.ComplaintQuery{
+		Page:    1,
+		PerPage: defaultPerPage,
+		Course:  values.Get("course"),
+		Sort:    values.Get("sort"),
+	}
+
+	var err error
+	if page := values.Get("page"); page != "" {
+		query.Page, err = strconv.Atoi(page)
+		if err != nil || query.Page < 1 {
+			return query, fmt.Errorf("page must be a positive number")
+		}
+	}
+	if perPage := values.Get("per_page"); perPage != "" {
+		query.PerPage, err = strconv.Atoi(perPage)
+		if err != nil || query.PerPage < 1 || query.PerPage > maxPerPage {
+			return query, fmt.Errorf("per_page must be between 1 and %d", maxPerPage)
+		}
+	}
+
+	for _, param := range values["status"] {
+		for _, status := range strings.Split(param, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				query.Statuses = append(query.Statuses, models.Status(status))
+			}
+		}
+	}
+
+	if from := values.Get("from"); from != "" {
+		query.From, err = parseDateParam(from, false)
+		if err != nil {
+			return query, fmt.Errorf("from: %w", err)
+		}
+	}
+	if to := values.Get("to"); to != "" {
+		query.To, err = parseDateParam(to, true)
+		if err != nil {
+			return query, fmt.Errorf("to: %w", err)
+		}
+	}
+
+	switch values.Get("order") {
+	case "", "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		return query, fmt.Errorf("order must be asc or desc")
+	}
+
+	return query, query.Validate()
+}
+
+// parseDateParam accepts a full RFC 3339 timestamp or a plain date. Both ends of a range are
+// inclusive, so a plain date used as the end stands for the last instant of that day.
+func parseDateParam(value string, endOfRange bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected YYYY-MM-DD or an RFC 3339 time, got %q", value)
+	}
+	if endOfRange {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t, nil
+}
@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"complaints/cmd/api/assignment"
+	"complaints/cmd/api/models"
+	"complaints/cmd/api/utilities"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type reassignRequest struct {
+	Lecturer string `json:"lecturer"`
+	Reason   string `json:"reason"`
+}
+
+// ReassignComplaint lets an HOD move a complaint of their department to another lecturer of its
+// course, at whatever stage it has reached
+func ReassignComplaint(w http.ResponseWriter, r *http.Request) {
+	complaint, ok := viewableComplaint(w, r)
+	if !ok {
+		return
+	}
+
+	var request reassignRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+	if request.Lecturer == "" {
+		utilities.ErrorJSON(w, errors.New("lecturer is required"))
+		return
+	}
+	if request.Lecturer == complaint.RespondingLecturer {
+		utilities.ErrorJSON(w, fmt.Errorf("complaint is already assigned to %s", request.Lecturer))
+		return
+	}
+
+	course, err := store.Courses.GetCourseByCourseCode(complaint.CourseConcerned)
+	if err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+	if !assignment.Teaches(course, request.Lecturer) {
+		utilities.ErrorJSON(w, fmt.Errorf("%s is not a lecturer of %s", request.Lecturer, course.CourseCode))
+		return
+	}
+
+	reason := request.Reason
+	if reason == "" {
+		reason = "reassigned by HOD"
+	}
+
+	err = reassign(r, complaint, request.Lecturer, assignment.Manual, reason, request.Reason)
+	if err != nil {
+		statusErrorJSON(w, err)
+		return
+	}
+
+	utilities.WriteJSON(w, http.StatusOK, "Complaint Reassigned Successfully", "Success")
+}
+
+// ReassignLecturerComplaints moves every open complaint of a lecturer who is leaving, within the
+// caller's departments, either to the lecturer named in the request or, for each complaint, to
+// whoever the assignment strategy picks among the course's other lecturers
+func ReassignLecturerComplaints(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+	staffID := params.ByName("id")
+
+	var request reassignRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+	if request.Lecturer == staffID {
+		utilities.ErrorJSON(w, errors.New("cannot reassign complaints to the same lecturer"))
+		return
+	}
+
+	// only the complaints of courses in the HOD's own departments are moved
+	userID, _ := currentUser(r)
+	courses, err := hodCourses(userID)
+	if err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+
+	query := models.ComplaintQuery{
+		Statuses: models.OpenStatuses(),
+		Courses:  courses,
+	}
+	open, err := store.Complaints.GetComplaintsByStaffId(staffID, query)
+	if err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+
+	type skipped struct {
+		ComplaintID string `json:"complaint_id"`
+		Reason      string `json:"reason"`
+	}
+	type reassigned struct {
+		ComplaintID string `json:"complaint_id"`
+		Lecturer    string `json:"lecturer"`
+	}
+	result := struct {
+		Reassigned []reassigned `json:"reassigned"`
+		Skipped    []skipped    `json:"skipped"`
+	}{
+		Reassigned: []reassigned{},
+		Skipped:    []skipped{},
+	}
+
+	for _, complaint := range open.Complaints {
+		lecturer, strategy, reason, err := replacementLecturer(complaint, staffID, request)
+		if err == nil {
+			err = reassign(r, complaint, lecturer, strategy, reason, request.Reason)
+		}
+		if err != nil {
+			result.Skipped = append(result.Skipped, skipped{complaint.ID.Hex(), err.Error()})
+			continue
+		}
+		result.Reassigned = append(result.Reassigned, reassigned{complaint.ID.Hex(), lecturer})
+	}
+
+	utilities.WriteJSON(w, http.StatusOK, result, "result")
+}
+
+// replacementLecturer chooses who takes over a complaint from a departing lecturer, returning
+// the lecturer with the strategy and reason to record as the new assignment
+func replacementLecturer(complaint models.Complaint, departing string, request reassignRequest) (string, string, string, error) {
+	course, err := store.Courses.GetCourseByCourseCode(complaint.CourseConcerned)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if request.Lecturer != "" {
+		if !assignment.Teaches(course, request.Lecturer) {
+			return "", "", "", fmt.Errorf("%s is not a lecturer of %s", request.Lecturer, course.CourseCode)
+		}
+		return request.Lecturer, assignment.Manual, "reassigned from " + departing + " by HOD", nil
+	}
+
+	lecturer, reason, err := assigner.Assign(assignment.Without(course, departing))
+	if err != nil {
+		return "", "", "", err
+	}
+	return lecturer, assigner.Name(), "reassigned from " + departing + ", " + reason, nil
+}
+
+// reassign records complaint moving to lecturer on behalf of the caller
+func reassign(r *http.Request, complaint models.Complaint, lecturer, strategy, assignmentReason, reason string) error {
+	now := time.Now()
+	return store.Complaints.ReassignComplaint(complaint.ID.Hex(), models.Reassignment{
+		From:   complaint.RespondingLecturer,
+		To:     lecturer,
+		Reason: reason,
+		Actor:  requestActor(r),
+		At:     now,
+	}, models.Assignment{
+		Strategy:   strategy,
+		Reason:     assignmentReason,
+		AssignedAt: now,
+	})
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"complaints/cmd/api/scanner"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"strings"
+)
+
+// malware checks every upload before it is stored
+var malware scanner.Scanner
+
+// SetScanner sets the malware scanner uploads are passed through
+func SetScanner(s scanner.Scanner) {
+	malware = s
+}
+
+// quarantinePrefix starts the keys of infected uploads. They are kept for review but never
+// referenced by a complaint, and serveFile refuses them.
+const quarantinePrefix = "quarantine/"
+
+// infectedError is returned for uploads the scanner found malware in
+type infectedError struct {
+	signature string
+}
+
+func (e infectedError) Error() string {
+	return fmt.Sprintf("the file was rejected by the malware scanner (%s)", e.signature)
+}
+
+// errScannerUnavailable is returned when an upload cannot be scanned; uploads are refused
+// rather than stored unchecked
+var errScannerUnavailable = fmt.Errorf("uploads cannot be checked for malware right now, try again later")
+
+// scanUpload passes an upload through the malware scanner and rewinds it for storing. An
+// infected file is copied to the quarantine under name and rejected with an infectedError.
+func scanUpload(ctx context.Context, file multipart.File, complaintID, name, contentType string) error {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	result, err := malware.Scan(ctx, file)
+	if err != nil {
+		log.Println("Unable to scan upload:", err)
+		return errScannerUnavailable
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if result.Clean {
+		return nil
+	}
+
+	key := quarantinePrefix + complaintID + "/" + name
+	log.Printf("Quarantined upload %s for complaint %s: %s", key, complaintID, result.Signature)
+	if _, err := files.Put(ctx, key, file, contentType); err != nil {
+		log.Println("Unable to quarantine upload:", err)
+	}
+	return infectedError{signature: result.Signature}
+}
+
+// isQuarantined reports whether key belongs to a quarantined upload
+func isQuarantined(key string) bool {
+	return strings.HasPrefix(key, quarantinePrefix)
+}
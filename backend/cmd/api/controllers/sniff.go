@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// uploadExtensions gives the extension stored files of each recognised type are saved with
+var uploadExtensions = map[string]string{
+	"application/pdf": ".pdf",
+	"image/png":       ".png",
+	"image/jpeg":      ".jpg",
+	"image/heic":      ".heic",
+	"image/heif":      ".heif",
+}
+
+// DefaultUploadTypes are the types accepted when no allowlist is configured
+var DefaultUploadTypes = []string{"application/pdf", "image/png", "image/jpeg", "image/heic"}
+
+// allowedUploadTypes is the allowlist uploads are checked against
+var allowedUploadTypes = DefaultUploadTypes
+
+// SetAllowedUploadTypes sets the MIME types uploads may have
+func SetAllowedUploadTypes(types []string) {
+	allowedUploadTypes = types
+}
+
+// unsupportedTypeError is returned for uploads whose content is not of an allowed type
+type unsupportedTypeError struct {
+	detected string
+}
+
+func (e unsupportedTypeError) Error() string {
+	return fmt.Sprintf("files of type %s are not accepted, expected one of %s", e.detected, strings.Join(allowedUploadTypes, ", "))
+}
+
+// sniffUpload detects the type of an upload from its first bytes, ignoring the name and the
+// type the client claims, and checks it against the allowlist. It consumes those bytes, so the
+// caller rewinds the file before reading it again.
+func sniffUpload(file io.Reader) (string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	head = head[:n]
+
+	detected := detectContentType(head)
+	if !containsString(allowedUploadTypes, detected) {
+		return "", unsupportedTypeError{detected: detected}
+	}
+	return detected, nil
+}
+
+// detectContentType extends http.DetectContentType, which covers PDF, PNG and JPEG, with the
+// HEIF container used for HEIC photos
+func detectContentType(head []byte) string {
+	if heif := detectHEIF(head); heif != "" {
+		return heif
+	}
+	contentType, _, _ := strings.Cut(http.DetectContentType(head), ";")
+	return contentType
+}
+
+// detectHEIF recognises the ISO base media "ftyp" box that starts HEIF files, telling HEIC
+// apart by its major or compatible brands
+func detectHEIF(head []byte) string {
+	if len(head) < 12 || string(head[4:8]) != "ftyp" {
+		return ""
+	}
+
+	size := int(head[0])<<24 | int(head[1])<<16 | int(head[2])<<8 | int(head[3])
+	if size < 16 || size > len(head) {
+		size = len(head)
+	}
+
+	brands := []string{string(head[8:12])}
+	for i := 16; i+4 <= size; i += 4 {
+		brands = append(brands, string(head[i:i+4]))
+	}
+
+	heif := false
+	for _, brand := range brands {
+		switch brand {
+		case "heic", "heix", "heim", "heis", "hevc", "hevx":
+			return "image/heic"
+		case "mif1", "msf1":
+			heif = true
+		}
+	}
+	if heif {
+		return "image/heif"
+	}
+	return ""
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"complaints/cmd/api/models"
+	"complaints/cmd/api/utilities"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// streamHeartbeat is how often an idle event stream sends a comment, so proxies keep it open
+	streamHeartbeat = 25 * time.Second
+	// streamScopeRefresh is how often a stream works out again what its caller may see, so
+	// changes to departments and advisors reach open dashboards
+	streamScopeRefresh = 5 * time.Minute
+)
+
+// streams holds a channel for every open event stream
+var streams = struct {
+	sync.Mutex
+	subscribers map[chan models.Event]struct{}
+}{subscribers: map[chan models.Event]struct{}{}}
+
+// BroadcastEvent passes a complaint event to every open event stream; have the store Watch with
+// it, so every API process streams every event. A stream that has fallen too far behind is
+// dropped, and its client reconnects.
+func BroadcastEvent(event models.Event) {
+	streams.Lock()
+	defer streams.Unlock()
+
+	for events := range streams.subscribers {
+		select {
+		case events <- event:
+		default:
+			delete(streams.subscribers, events)
+			close(events)
+		}
+	}
+}
+
+// streamEvent is what a stream sends for each event
+type streamEvent struct {
+	ID                 string        `json:"id"`
+	Type               string        `json:"type"`
+	ComplaintID        string        `json:"complaint_id"`
+	Status             models.Status `json:"status"`
+	From               models.Status `json:"from,omitempty"`
+	CourseConcerned    string        `json:"course_concerned"`
+	RequestingStudent  string        `json:"requesting_student"`
+	RespondingLecturer string        `json:"responding_lecturer,omitempty"`
+	At                 time.Time     `json:"at"`
+}
+
+// StreamEvents sends the caller complaint created, status changed and reassigned events as
+// Server-Sent Events, for the complaints the caller may see
+func StreamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		utilities.ErrorJSON(w, errors.New("streaming is not supported"), http.StatusInternalServerError)
+		return
+	}
+
+	scope, err := callerScope(r)
+	if err != nil {
+		utilities.ErrorJSON(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	events := make(chan models.Event, 64)
+	streams.Lock()
+	streams.subscribers[events] = struct{}{}
+	streams.Unlock()
+	defer func() {
+		streams.Lock()
+		if _, ok := streams.subscribers[events]; ok {
+			delete(streams.subscribers, events)
+			close(events)
+		}
+		streams.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 5000\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	refresh := time.NewTicker(streamScopeRefresh)
+	defer refresh.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case <-refresh.C:
+			if refreshed, err := callerScope(r); err != nil {
+				log.Println("Unable to refresh event stream scope:", err)
+			} else {
+				scope = refreshed
+			}
+			continue
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if !canSeeEvent(scope, event) {
+				continue
+			}
+			data, err := json.Marshal(newStreamEvent(event))
+			if err != nil {
+				log.Println("Unable to encode event:", err)
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID.Hex(), event.Type, data)
+		}
+		flusher.Flush()
+	}
+}
+
+// canSeeEvent reports whether a stream's caller may see the complaint an event is about, and the
+// comment of a comment event. A lecturer also sees a complaint being reassigned away from them.
+func canSeeEvent(scope viewScope, event models.Event) bool {
+	if event.Comment != nil && !containsString(event.Comment.VisibleTo, scope.role) {
+		return false
+	}
+	if event.Reassignment != nil && scope.role == models.RoleLecturer && event.Reassignment.From == scope.userID {
+		return true
+	}
+	return scope.canView(event.Complaint)
+}
+
+func newStreamEvent(event models.Event) streamEvent {
+	complaint := event.Complaint
+	out := streamEvent{
+		ID:                 event.ID.Hex(),
+		Type:               string(event.Type),
+		ComplaintID:        complaint.ID.Hex(),
+		Status:             complaint.Status,
+		CourseConcerned:    complaint.CourseConcerned,
+		RequestingStudent:  complaint.RequestingStudent,
+		RespondingLecturer: complaint.RespondingLecturer,
+		At:                 event.At,
+	}
+	if event.Change != nil {
+		out.From = event.Change.From
+	}
+	return out
+}
@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"bytes"
+	"complaints/cmd/api/models"
+	"complaints/cmd/api/scanner"
+	"complaints/cmd/api/storage"
+	"complaints/cmd/api/utilities"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// files keeps the proof documents uploaded with complaints
+var files storage.Backend
+
+// SetStorage sets where uploaded files are kept
+func SetStorage(b storage.Backend) {
+	files = b
+}
+
+// maxUploadFiles bounds how many files one request may upload
+const maxUploadFiles = 10
+
+// saveUploads stores every file sent in the "file" fields of a parsed multipart request. If
+// any file is rejected, the ones already stored are removed again.
+func saveUploads(r *http.Request, complaintID string) ([]models.Attachment, error) {
+	var handlers []*multipart.FileHeader
+	if r.MultipartForm != nil {
+		handlers = r.MultipartForm.File["file"]
+	}
+	if len(handlers) > maxUploadFiles {
+		return nil, fmt.Errorf("at most %d files can be uploaded at once", maxUploadFiles)
+	}
+
+	var attachments []models.Attachment
+	for _, handler := range handlers {
+		attachment, err := saveUpload(r, complaintID, handler)
+		if err != nil {
+			discardUploads(r.Context(), attachments)
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, nil
+}
+
+// saveUpload checks an uploaded file's type, scans it for malware, strips image metadata and
+// stores it for a complaint, with a thumbnail for images. Files are keyed by
+// complaint and a random name with an extension matching the detected type, so keys can
+// neither collide nor leave the complaint's directory, and nothing of the client's filename
+// but the recorded Filename is kept.
+func saveUpload(r *http.Request, complaintID string, handler *multipart.FileHeader) (models.Attachment, error) {
+	if !isHexID(complaintID) {
+		return models.Attachment{}, errors.New("invalid complaint ID for upload")
+	}
+
+	file, err := handler.Open()
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	defer file.Close()
+
+	contentType, err := sniffUpload(file)
+	if err != nil {
+		return models.Attachment{}, err
+	}
+
+	name, err := uploadName(uploadExtensions[contentType])
+	if err != nil {
+		return models.Attachment{}, err
+	}
+
+	if err := scanUpload(r.Context(), file, complaintID, name, contentType); err != nil {
+		return models.Attachment{}, err
+	}
+	scannedBy := ""
+	if malware.Name() != scanner.None {
+		scannedBy = malware.Name()
+	}
+
+	var content io.Reader = file
+	cleaned, thumbnail, err := cleanImage(contentType, file)
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	if cleaned != nil {
+		content = bytes.NewReader(cleaned)
+	}
+
+	hash := sha256.New()
+	key := complaintID + "/" + name
+	size, err := files.Put(r.Context(), key, io.TeeReader(content, hash), contentType)
+	if err != nil {
+		return models.Attachment{}, err
+	}
+
+	thumbnailKey := ""
+	if thumbnail != nil {
+		thumbnailKey = strings.TrimSuffix(key, path.Ext(key)) + thumbnailSuffix
+		if _, err := files.Put(r.Context(), thumbnailKey, bytes.NewReader(thumbnail), "image/jpeg"); err != nil {
+			discardUploads(r.Context(), []models.Attachment{{Key: key}})
+			return models.Attachment{}, err
+		}
+	}
+
+	return models.Attachment{
+		ID:           primitive.NewObjectID(),
+		Key:          key,
+		Filename:     filepath.Base(handler.Filename),
+		ContentType:  contentType,
+		Size:         size,
+		SHA256:       hex.EncodeToString(hash.Sum(nil)),
+		UploadedBy:   requestActor(r),
+		CreatedAt:    time.Now(),
+		ScannedBy:    scannedBy,
+		ThumbnailKey: thumbnailKey,
+	}, nil
+}
+
+// discardUploads removes stored files that did not end up recorded on a complaint
+func discardUploads(ctx context.Context, attachments []models.Attachment) {
+	for _, attachment := range attachments {
+		for _, key := range []string{attachment.Key, attachment.ThumbnailKey} {
+			if key == "" {
+				continue
+			}
+			if err := files.Delete(ctx, key); err != nil {
+				log.Println("Unable to remove unused upload:", err)
+			}
+		}
+	}
+}
+
+// uploadErrorJSON reports a failed upload, using 415 for files of a type that is not accepted,
+// 422 for infected files and 503 when the scanner cannot be reached
+func uploadErrorJSON(w http.ResponseWriter, err error) {
+	var unsupported unsupportedTypeError
+	if errors.As(err, &unsupported) {
+		utilities.ErrorJSON(w, err, http.StatusUnsupportedMediaType)
+		return
+	}
+	var infected infectedError
+	if errors.As(err, &infected) {
+		utilities.ErrorJSON(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+	if errors.Is(err, errScannerUnavailable) {
+		utilities.ErrorJSON(w, err, http.StatusServiceUnavailable)
+		return
+	}
+	utilities.ErrorJSON(w, err)
+}
+
+// uploadName returns a random file name ending in ext
+func uploadName(ext string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("unable to name upload: %w", err)
+	}
+	return hex.EncodeToString(random) + ext, nil
+}
+
+func isHexID(id string) bool {
+	if id == "" {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"complaints/cmd/api/models"
+	"complaints/cmd/api/utilities"
+	"complaints/cmd/api/webhook"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultDeliveries = 50
+	maxDeliveries     = 500
+)
+
+// CreateWebhook subscribes an https URL on a public address, or any URL webhook.CheckURL lets
+// through, to the event types in the body. A
+// secret for signing the deliveries is generated unless one is given, and is only returned here.
+func CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		URL    string             `json:"url"`
+		Events []models.EventType `json:"events"`
+		Secret string             `json:"secret"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+
+	if err := webhook.CheckURL(r.Context(), request.URL); err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+	if len(request.Events) == 0 {
+		utilities.ErrorJSON(w, fmt.Errorf("events must list at least one of %v", models.EventTypes))
+		return
+	}
+	for _, event := range request.Events {
+		if !knownEventType(event) {
+			utilities.ErrorJSON(w, fmt.Errorf("unknown event type %q, expected one of %v", event, models.EventTypes))
+			return
+		}
+	}
+
+	if request.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			utilities.ErrorJSON(w, err, http.StatusInternalServerError)
+			return
+		}
+		request.Secret = hex.EncodeToString(secret)
+	}
+
+	userID, _ := currentUser(r)
+	webhook := models.Webhook{
+		ID:        primitive.NewObjectID(),
+		URL:       request.URL,
+		Events:    request.Events,
+		Secret:    request.Secret,
+		CreatedBy: userID,
+		CreatedAt: time.Now(),
+	}
+	if _, err := store.Webhooks.CreateWebhook(webhook); err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+
+	utilities.WriteJSON(w, http.StatusCreated, webhook, "webhook")
+}
+
+func knownEventType(eventType models.EventType) bool {
+	for _, t := range models.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// GetWebhooks lists the webhooks, without their secrets
+func GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := store.Webhooks.GetWebhooks()
+	if err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	utilities.WriteJSON(w, http.StatusOK, webhooks, "webhooks")
+}
+
+// DeleteWebhook unsubscribes a webhook; deliveries waiting to be retried are dropped
+func DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(httprouter.ParamsFromContext(r.Context()).ByName("id"))
+	if err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+
+	if err := store.Webhooks.DeleteWebhook(id); err != nil {
+		webhookErrorJSON(w, err)
+		return
+	}
+
+	utilities.WriteJSON(w, http.StatusOK, "Webhook deleted", "Success")
+}
+
+// GetWebhookDeliveries lists the newest delivery attempts of a webhook, ?limit of them
+func GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(httprouter.ParamsFromContext(r.Context()).ByName("id"))
+	if err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+
+	limit := defaultDeliveries
+	if l := r.URL.Query().Get("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 || limit > maxDeliveries {
+			utilities.ErrorJSON(w, fmt.Errorf("limit must be between 1 and %d", maxDeliveries))
+			return
+		}
+	}
+
+	if _, err := store.Webhooks.GetWebhook(id); err != nil {
+		webhookErrorJSON(w, err)
+		return
+	}
+	deliveries, err := store.Webhooks.GetDeliveries(id, limit)
+	if err != nil {
+		utilities.ErrorJSON(w, err)
+		return
+	}
+
+	utilities.WriteJSON(w, http.StatusOK, deliveries, "deliveries")
+}
+
+func webhookErrorJSON(w http.ResponseWriter, err error) {
+	if errors.Is(err, models.ErrWebhookNotFound) {
+		utilities.ErrorJSON(w, err, http.StatusNotFound)
+		return
+	}
+	utilities.ErrorJSON(w, err)
+}
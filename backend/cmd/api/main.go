@@ -1,21 +1,161 @@
 package main
 
 import (
+	"complaints/cmd/api/assignment"
+	"complaints/cmd/api/config"
+	"complaints/cmd/api/controllers"
 	"complaints/cmd/api/models"
+	"complaints/cmd/api/notify"
 	"complaints/cmd/api/routes"
+	"complaints/cmd/api/scanner"
+	"complaints/cmd/api/sla"
+	"complaints/cmd/api/storage"
+	"complaints/cmd/api/webhook"
 	"log"
 	"net/http"
+
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func main() {
 
-	err := models.ConnectToDB()
+	cfg, err := config.GetConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	store, err := openStore(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open %s store: %v", cfg.Store, err)
+	}
+
+	assigner, err := assignment.New(cfg.AssignmentStrategy, store.Complaints)
+	if err != nil {
+		log.Fatalf("Failed to set up lecturer assignment: %v", err)
+	}
+
+	files, err := openStorage(cfg)
 	if err != nil {
-		log.Fatalf("Failed to connect to MongoDB: %v", err)
+		log.Fatalf("Failed to open %s storage: %v", cfg.Storage, err)
+	}
+
+	malware, err := scanner.New(cfg.Scanner, cfg.ClamdAddress)
+	if err != nil {
+		log.Fatalf("Failed to set up %s scanner: %v", cfg.Scanner, err)
+	}
+
+	calendar, err := sla.LoadCalendar(cfg.SLAHolidays, cfg.SLALocation)
+	if err != nil {
+		log.Fatalf("Failed to load holidays: %v", err)
+	}
+	store.SetDeadlines(sla.Policy{
+		Days: map[models.Status]int{
+			models.StatusPending:            cfg.SLALecturerDays,
+			models.StatusApprovedByLecturer: cfg.SLAAdvisorDays,
+			models.StatusApprovedByAdvisor:  cfg.SLAHODDays,
+			models.StatusApprovedByHOD:      cfg.SLASenateDays,
+		},
+		Calendar: calendar,
+	}.DueAt)
+
+	if err := startNotifications(cfg, store); err != nil {
+		log.Fatalf("Failed to set up notifications: %v", err)
+	}
+
+	controllers.SetStore(store)
+	store.Watch(controllers.BroadcastEvent)
+	if cfg.WebhookAllowPrivate {
+		log.Println("WEBHOOK_ALLOW_PRIVATE is on: webhooks may reach internal addresses over http")
+	}
+	webhook.SetAllowPrivate(cfg.WebhookAllowPrivate)
+	store.Subscribe(webhook.New(store.Webhooks, store.Jobs, cfg.WebhookAttempts, cfg.WebhookBackoff).Handle)
+	store.StartDispatcher()
+	sla.NewWorker(store.Complaints, cfg.SLARemindBefore, cfg.SLACheckInterval).Start()
+	controllers.SetAssigner(assigner)
+	controllers.SetStorage(files)
+	controllers.SetScanner(malware)
+	if len(cfg.UploadTypes) > 0 {
+		controllers.SetAllowedUploadTypes(cfg.UploadTypes)
 	}
+	controllers.SetDownloadSigning([]byte(cfg.DownloadKey), cfg.DownloadTTL)
 
 	router := routes.InitRoutes() // Call the InitRoutes function
 	port := "4000"
 	log.Printf("Server listening on port %s", port)
 	log.Fatal(http.ListenAndServe(":"+port, router))
 }
+
+// openStore returns the repository implementation selected by the configuration
+func openStore(cfg *config.Config) (*models.Store, error) {
+	if cfg.Store == "memory" {
+		seed, err := models.LoadMemorySeed(cfg.SeedFile)
+		if err != nil {
+			return nil, err
+		}
+		log.Println("using in-memory store, data will not survive a restart")
+		return models.NewMemoryStore(seed), nil
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewMongoStore(db), nil
+}
+
+// openStorage returns the attachment storage selected by the configuration
+func openStorage(cfg *config.Config) (storage.Backend, error) {
+	switch cfg.Storage {
+	case storage.S3:
+		return storage.NewS3(storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Bucket:    cfg.S3Bucket,
+			Region:    cfg.S3Region,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		})
+	case storage.GridFS:
+		db, err := openDatabase(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return storage.NewGridFS(db)
+	}
+	return storage.NewLocal(cfg.UploadsDir)
+}
+
+// startNotifications subscribes the in-app inbox, and email if configured, to the store's events
+func startNotifications(cfg *config.Config, store *models.Store) error {
+	channels := []notify.Channel{notify.NewInbox(store.Notifications)}
+	if cfg.Mailer == "smtp" {
+		sender, err := notify.NewSMTP(notify.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+		})
+		if err != nil {
+			return err
+		}
+		channels = append(channels, notify.NewMailer(sender, store.Jobs, cfg.AppURL))
+	}
+
+	store.Subscribe(notify.New(store, channels...).Handle)
+	return nil
+}
+
+var database *mongo.Database
+
+// openDatabase connects to MongoDB the first time something needs it, so the store and the
+// attachment storage share a connection
+func openDatabase(cfg *config.Config) (*mongo.Database, error) {
+	if database == nil {
+		client, err := models.ConnectToDB(cfg.MongoURI)
+		if err != nil {
+			return nil, err
+		}
+		database = client.Database(cfg.DbName)
+	}
+	return database, nil
+}
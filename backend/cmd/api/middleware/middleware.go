@@ -72,12 +72,35 @@ func Authenticate(next http.Handler) http.Handler {
 			return
 		}
 
-		userID := claims["iss"].(string)
+		userID, ok := claims["iss"].(string)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		role, _ := claims["role"].(string)
 
-		//store matric number in the request context
+		//store matric number and role in the request context
 		ctx := context.WithValue(r.Context(), "userID", userID)
+		ctx = context.WithValue(ctx, "role", role)
 		r = r.WithContext(ctx)
 
 		next.ServeHTTP(w, r)
 	})
 }
+
+// RequireRole only lets a request through if Authenticate stored one of the given roles in its context
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, _ := r.Context().Value("role").(string)
+			for _, allowed := range roles {
+				if role != "" && role == allowed {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		})
+	}
+}
@@ -2,49 +2,83 @@ package models
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
-	"os"
+	"time"
 
-	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func init() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file: ", err)
-	}
+// mongoStore implements every repository in Store on top of a MongoDB database
+type mongoStore struct {
+	db        *mongo.Database
+	events    *eventBus
+	deadlines *Deadlines
 }
 
-var client *mongo.Client
-
-func ConnectToDB() error {
-	mongoURI := os.Getenv("MONGOURI")
+func ConnectToDB(mongoURI string) (*mongo.Client, error) {
 	if mongoURI == "" {
-		return fmt.Errorf("MONGOURI environment variable not set")
+		return nil, fmt.Errorf("MONGOURI environment variable not set")
 	}
 	clientOptions := options.Client().ApplyURI(mongoURI)
-	var err error
-	client, err = mongo.Connect(context.Background(), clientOptions)
+	client, err := mongo.Connect(context.Background(), clientOptions)
 	if err != nil {
-		return fmt.Errorf("error connecting to MongoDB: %w", err)
+		return nil, fmt.Errorf("error connecting to MongoDB: %w", err)
 	}
 	log.Println("connected successfully")
-	return nil
+	return client, nil
+}
+
+// NewMongoStore returns a Store backed by the given MongoDB database
+func NewMongoStore(db *mongo.Database) *Store {
+	s := &mongoStore{db: db, deadlines: new(Deadlines)}
+	s.events = newEventBus(s)
+	if err := s.createIndexes(); err != nil {
+		log.Println("Unable to create indexes:", err)
+	}
+	return &Store{
+		Users:         s,
+		Complaints:    s,
+		Courses:       s,
+		Students:      s,
+		Lecturers:     s,
+		Departments:   s,
+		Advisors:      s,
+		Notifications: s,
+		Webhooks:      s,
+		Comments:      s,
+		Jobs:          s,
+		events:        s.events,
+		deadlines:     s.deadlines,
+	}
+}
+
+// createIndexes sets up the indexes the store relies on. Creating an index that exists already
+// does nothing.
+func (s *mongoStore) createIndexes() error {
+	// MongoDB deletes done jobs once jobRetention has passed
+	_, err := s.GetDBCollection("Jobs").Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "done_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(jobRetention / time.Second)),
+	})
+	return err
 }
 
 // GetDBCollection returns a reference to a collection in a MongoDB database
-func GetDBCollection(collectionName string) *mongo.Collection {
-	dbName := os.Getenv("DB_NAME")
-	return client.Database(dbName).Collection(collectionName)
+func (s *mongoStore) GetDBCollection(collectionName string) *mongo.Collection {
+	return s.db.Collection(collectionName)
 }
 
-func Register(user User) (string, error) {
-	collection := GetDBCollection("Users")
+func (s *mongoStore) Register(user User) (string, error) {
+	collection := s.GetDBCollection("Users")
+
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
 
 	_, err := collection.InsertOne(context.Background(), user)
 	if err != nil {
@@ -56,40 +90,67 @@ func Register(user User) (string, error) {
 	return oid, nil
 }
 
-func GetUserByObjectID(id primitive.ObjectID) (User, error) {
+func (s *mongoStore) GetUserByObjectID(id primitive.ObjectID) (User, error) {
 	var user User
-	collection := GetDBCollection("Users")
+	collection := s.GetDBCollection("Users")
 
 	err := collection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return User{}, fmt.Errorf("User not found")
+			return User{}, ErrUserNotFound
 		}
 		return User{}, err
 	}
 	return user, nil
 }
 
-func GetUserByUserID(userID string) (User, error) {
+func (s *mongoStore) GetUserByUserID(userID string) (User, error) {
 	var user User
-	collection := GetDBCollection("Users")
+	collection := s.GetDBCollection("Users")
 
 	err := collection.FindOne(context.Background(), bson.M{"user_id": userID}).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return User{}, fmt.Errorf("User not found")
+			return User{}, ErrUserNotFound
 		}
 		return User{}, err
 	}
 	return user, nil
 }
 
-func CreateNewComplaint(complaint Complaint) (string, error) {
-	collection := GetDBCollection("Complaints")
+// GetUsersByRole returns every user with the given role
+func (s *mongoStore) GetUsersByRole(role string) ([]User, error) {
+	collection := s.GetDBCollection("Users")
+
+	cursor, err := collection.Find(context.Background(), bson.M{"role": role})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var users []User
+	if err := cursor.All(context.Background(), &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (s *mongoStore) CreateNewComplaint(complaint Complaint) (string, error) {
+	collection := s.GetDBCollection("Complaints")
+
+	if complaint.ID.IsZero() {
+		complaint.ID = primitive.NewObjectID()
+	}
+	complaint.DueAt = s.deadlines.dueAt(complaint.Status, complaint.CreatedAt)
 
-	_, err := collection.InsertOne(context.Background(), complaint)
+	err := s.withEvent(func(ctx mongo.SessionContext) (Event, error) {
+		if _, err := collection.InsertOne(ctx, complaint); err != nil {
+			return Event{}, fmt.Errorf("failed to insert complaint: %w", err)
+		}
+		return newEvent(EventComplaintCreated, complaint), nil
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to insert complaint: %w", err)
+		return "", err
 	}
 
 	oid := complaint.ID.Hex()
@@ -97,8 +158,8 @@ func CreateNewComplaint(complaint Complaint) (string, error) {
 	return oid, nil
 }
 
-func GetCourseByCourseCode(courseCode string) (Course, error) {
-	collection := GetDBCollection("Courses")
+func (s *mongoStore) GetCourseByCourseCode(courseCode string) (Course, error) {
+	collection := s.GetDBCollection("Courses")
 
 	filter := bson.M{
 		"course_code": courseCode,
@@ -108,15 +169,15 @@ func GetCourseByCourseCode(courseCode string) (Course, error) {
 	err := collection.FindOne(context.Background(), filter).Decode(&course)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return Course{}, fmt.Errorf("Course not found")
+			return Course{}, ErrCourseNotFound
 		}
 		return Course{}, err
 	}
 	return course, nil
 }
 
-func GetMatricNo(email string) (string, error) {
-	collection := GetDBCollection("Students")
+func (s *mongoStore) GetMatricNo(email string) (string, error) {
+	collection := s.GetDBCollection("Students")
 
 	filter := bson.M{
 		"email": email,
@@ -134,9 +195,9 @@ func GetMatricNo(email string) (string, error) {
 	return result.MatricNo, nil
 }
 
-func GetComplaintByObjectId(id primitive.ObjectID) (Complaint, error) {
+func (s *mongoStore) GetComplaintByObjectId(id primitive.ObjectID) (Complaint, error) {
 	var complaint Complaint
-	collection := GetDBCollection("Complaints")
+	collection := s.GetDBCollection("Complaints")
 	filter := bson.M{
 		"_id": id,
 	}
@@ -144,41 +205,232 @@ func GetComplaintByObjectId(id primitive.ObjectID) (Complaint, error) {
 	err := collection.FindOne(context.Background(), filter).Decode(&complaint)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return Complaint{}, fmt.Errorf("Complaint not found")
+			return Complaint{}, ErrComplaintNotFound
 		}
 		return Complaint{}, err
 	}
 	return complaint, nil
 }
 
-func GetComplaintsByStaffId(id string) ([]Complaint, error) {
-	collection := GetDBCollection("Complaints")
-
+func (s *mongoStore) GetComplaintsByStaffId(id string, query ComplaintQuery) (ComplaintPage, error) {
 	filter := bson.M{"responding_lecturer": id}
 
-	cursor, err := collection.Find(context.Background(), filter)
+	return s.findComplaints(filter, query)
+}
+
+// CountOpenComplaintsByStaffId counts the complaints assigned to a lecturer that are not yet final
+func (s *mongoStore) CountOpenComplaintsByStaffId(id string) (int64, error) {
+	collection := s.GetDBCollection("Complaints")
+
+	filter := bson.M{
+		"responding_lecturer": id,
+		"status":              bson.M{"$in": OpenStatuses()},
+	}
+
+	return collection.CountDocuments(context.Background(), filter)
+}
+
+func (s *mongoStore) GetComplaintsByStudentId(id string, query ComplaintQuery) (ComplaintPage, error) {
+	filter := bson.M{
+		"requesting_student": id,
+	}
+
+	return s.findComplaints(filter, query)
+}
+
+// ChangeComplaintStatus moves a complaint to newStatus, rejecting moves the workflow does not allow
+func (s *mongoStore) ChangeComplaintStatus(id string, newStatus Status, actor Actor) error {
+	return s.transitionComplaint(id, StatusChange{To: newStatus, Actor: actor}, bson.M{})
+}
+
+func (s *mongoStore) ChangeComplaintStatusLecturer(id string, newStatus Status, reason string, proofs []Attachment, actor Actor) error {
+	change := lecturerChange(newStatus, reason, proofs, actor)
+	return s.transitionComplaint(id, change, bson.M{
+		"reason":         reason,
+		"lecturer_proof": change.Attachment,
+	})
+}
+
+// DeclineComplaint declines a complaint at whatever stage it has reached. A reason is required
+// so the student can see why; proofs are optional supporting documents.
+func (s *mongoStore) DeclineComplaint(id string, reason string, proofs []Attachment, actor Actor) error {
+	change, err := declineChange(reason, proofs, actor)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer cursor.Close(context.Background())
+	return s.transitionComplaint(id, change, bson.M{})
+}
 
-	var complaints []Complaint
-	for cursor.Next(context.Background()) {
-		var complaint Complaint
-		err := cursor.Decode(&complaint)
+func (s *mongoStore) ChangeStatusToByHOD(id string, actor Actor) error {
+	return s.ChangeComplaintStatus(id, StatusApprovedByHOD, actor)
+}
+
+// transitionComplaint applies set together with the new status and appends change to the
+// complaint's history. The update only matches while the complaint is still in the status it
+// was read in, so the check, the status change and the history entry are applied atomically
+// and a concurrent change makes this one fail rather than overwrite it.
+func (s *mongoStore) transitionComplaint(id string, change StatusChange, set bson.M) error {
+	collection := s.GetDBCollection("Complaints")
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	complaint, err := s.GetComplaintByObjectId(objectID)
+	if err != nil {
+		return err
+	}
+	change, err = prepareTransition(complaint, change)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{
+		"_id":    objectID,
+		"status": complaint.Status,
+	}
+	if complaint.Escalated {
+		// the move may only be allowed because of the escalation
+		filter["escalated"] = true
+	}
+
+	set["status"] = change.To
+	set["updated_at"] = change.At
+	if decline := declineFor(change); decline != nil {
+		set["decline"] = decline
+	}
+	unset := bson.M{"reminded_at": "", "escalated": ""}
+	if dueAt := s.deadlines.dueAt(change.To, change.At); dueAt != nil {
+		set["due_at"] = dueAt
+	} else {
+		unset["due_at"] = ""
+	}
+	push := bson.M{"history": change}
+	if len(change.files) > 0 {
+		push["attachments"] = bson.M{"$each": change.files}
+	}
+	update := bson.M{
+		"$set":   set,
+		"$unset": unset,
+		"$push":  push,
+	}
+
+	change.files = nil
+	return s.withEvent(func(ctx mongo.SessionContext) (Event, error) {
+		var updated Complaint
+		err := collection.FindOneAndUpdate(ctx, filter, update, returnUpdated).Decode(&updated)
+		if err == mongo.ErrNoDocuments {
+			return Event{}, fmt.Errorf("%w: complaint was changed by someone else, reload and try again", ErrInvalidTransition)
+		}
+		if err != nil {
+			return Event{}, err
+		}
+		event := newEvent(EventStatusChanged, updated)
+		event.Change = &change
+		return event, nil
+	})
+}
+
+// returnUpdated makes FindOneAndUpdate return a document as it is after the update
+var returnUpdated = options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+// withEvent runs fn in a transaction and records the event it returns in the outbox in the same
+// transaction, so a change and its event are saved together or not at all. Transactions need
+// MongoDB to run as a replica set or sharded cluster.
+func (s *mongoStore) withEvent(fn func(ctx mongo.SessionContext) (Event, error)) error {
+	session, err := s.db.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(context.Background())
+
+	_, err = session.WithTransaction(context.Background(), func(ctx mongo.SessionContext) (interface{}, error) {
+		event, err := fn(ctx)
 		if err != nil {
 			return nil, err
 		}
-		complaints = append(complaints, complaint)
+		_, err = s.GetDBCollection("Outbox").InsertOne(ctx, outboxEntry{Event: event})
+		return nil, err
+	})
+	if err != nil {
+		return err
 	}
 
-	return complaints, nil
+	s.events.notify()
+	return nil
 }
 
-func GetComplaintsByStudentId(id string) ([]Complaint, error) {
-	collection := GetDBCollection("Complaints")
-	filter := bson.M{
-		"requesting_student": id,
+func (s *mongoStore) claimEvent(lease time.Duration) (Event, bool, error) {
+	collection := s.GetDBCollection("Outbox")
+
+	now := time.Now()
+	filter := bson.M{"claimed_until": bson.M{"$lte": now}}
+	update := bson.M{"$set": bson.M{"claimed_until": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().SetSort(bson.D{{Key: "_id", Value: 1}})
+
+	var entry outboxEntry
+	err := collection.FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		return Event{}, false, nil
+	}
+	if err != nil {
+		return Event{}, false, err
+	}
+	return entry.Event, true, nil
+}
+
+func (s *mongoStore) markDispatched(id primitive.ObjectID) error {
+	collection := s.GetDBCollection("Outbox")
+
+	_, err := collection.DeleteOne(context.Background(), bson.M{"_id": id})
+	return err
+}
+
+// watchEvents follows the events inserted into the outbox with a change stream, which like
+// transactions needs MongoDB to run as a replica set. Events recorded while the stream is down
+// for longer than its resume token lasts are missed.
+func (s *mongoStore) watchEvents(fn func(Event)) {
+	collection := s.GetDBCollection("Outbox")
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{"operationType": "insert"}}}}
+
+	var resumeToken bson.Raw
+	for {
+		opts := options.ChangeStream()
+		if resumeToken != nil {
+			opts.SetResumeAfter(resumeToken)
+		}
+		stream, err := collection.Watch(context.Background(), pipeline, opts)
+		if err != nil {
+			log.Println("Unable to watch the event outbox:", err)
+			resumeToken = nil
+			time.Sleep(outboxPoll)
+			continue
+		}
+
+		for stream.Next(context.Background()) {
+			var change struct {
+				FullDocument outboxEntry `bson:"fullDocument"`
+			}
+			if err := stream.Decode(&change); err != nil {
+				log.Println("Unable to decode outbox change:", err)
+				continue
+			}
+			fn(change.FullDocument.Event)
+		}
+		log.Println("Stopped watching the event outbox:", stream.Err())
+		resumeToken = stream.ResumeToken()
+		stream.Close(context.Background())
+	}
+}
+
+// GetStudentsByProgram returns the students of a program, limited to the given levels if any
+func (s *mongoStore) GetStudentsByProgram(program string, levels []int) ([]Student, error) {
+	collection := s.GetDBCollection("Students")
+
+	filter := bson.M{"program": program}
+	if len(levels) > 0 {
+		filter["level"] = bson.M{"$in": levels}
 	}
 
 	cursor, err := collection.Find(context.Background(), filter)
@@ -187,154 +439,376 @@ func GetComplaintsByStudentId(id string) ([]Complaint, error) {
 	}
 	defer cursor.Close(context.Background())
 
-	var complaints []Complaint
-	for cursor.Next(context.Background()) {
-		var complaint Complaint
-		err := cursor.Decode(&complaint)
-		if err != nil {
-			return nil, err
-		}
-		complaints = append(complaints, complaint)
+	var students []Student
+	if err := cursor.All(context.Background(), &students); err != nil {
+		return nil, err
 	}
-
-	return complaints, nil
+	return students, nil
 }
 
-func ChangeComplaintStatus(id string, newStatus string) error {
-	collection := GetDBCollection("Complaints")
+// GetAdvisorsByUserID returns every program a user advises
+func (s *mongoStore) GetAdvisorsByUserID(userID string) ([]Advisor, error) {
+	collection := s.GetDBCollection("Advisors")
 
-	objectID, err := primitive.ObjectIDFromHex(id)
+	cursor, err := collection.Find(context.Background(), bson.M{"user_id": userID})
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer cursor.Close(context.Background())
 
-	filter := bson.M{"_id": objectID}
+	var advisors []Advisor
+	if err := cursor.All(context.Background(), &advisors); err != nil {
+		return nil, err
+	}
+	return advisors, nil
+}
 
-	update := bson.M{
-		"$set": bson.M{
-			"status": newStatus,
+// GetAdvisorsForStudent returns the advisors of a student's program and level
+func (s *mongoStore) GetAdvisorsForStudent(student Student) ([]Advisor, error) {
+	collection := s.GetDBCollection("Advisors")
+
+	filter := bson.M{
+		"program": student.Program,
+		"$or": bson.A{
+			bson.M{"levels": bson.M{"$exists": false}},
+			bson.M{"levels": bson.M{"$size": 0}},
+			bson.M{"levels": student.Level},
 		},
 	}
+	cursor, err := collection.Find(context.Background(), filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var advisors []Advisor
+	if err := cursor.All(context.Background(), &advisors); err != nil {
+		return nil, err
+	}
+	return advisors, nil
+}
+
+// GetDepartmentsByHOD returns the departments a user is HOD of
+func (s *mongoStore) GetDepartmentsByHOD(userID string) ([]Department, error) {
+	collection := s.GetDBCollection("Departments")
 
-	_, err = collection.UpdateOne(context.Background(), filter, update)
+	cursor, err := collection.Find(context.Background(), bson.M{"hods": userID})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	defer cursor.Close(context.Background())
+
+	var departments []Department
+	if err := cursor.All(context.Background(), &departments); err != nil {
+		return nil, err
+	}
+	return departments, nil
+}
+
+func (s *mongoStore) GetDepartmentByCourseCode(courseCode string) (Department, error) {
+	var department Department
+	collection := s.GetDBCollection("Departments")
+
+	err := collection.FindOne(context.Background(), bson.M{"courses": courseCode}).Decode(&department)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Department{}, ErrDepartmentNotFound
+		}
+		return Department{}, err
+	}
+	return department, nil
 }
 
-func ChangeComplaintStatusLecturer(id string, newStatus string, reason string, lecturer_proof string) error {
-	collection := GetDBCollection("Complaints")
+// ReassignComplaint hands an open complaint from reassignment.From to reassignment.To. Like a
+// status change it only applies if the complaint is still open, in the status it was read in
+// and with the expected lecturer. A pending complaint gets a new deadline with its new lecturer.
+func (s *mongoStore) ReassignComplaint(id string, reassignment Reassignment, assignment Assignment) error {
+	collection := s.GetDBCollection("Complaints")
 
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return err
 	}
 
-	filter := bson.M{"_id": objectID}
+	complaint, err := s.GetComplaintByObjectId(objectID)
+	if err != nil {
+		return err
+	}
+	if complaint.Status.IsFinal() || complaint.RespondingLecturer != reassignment.From {
+		return reassignConflict(complaint, reassignment)
+	}
 
+	filter := bson.M{
+		"_id":                 objectID,
+		"responding_lecturer": reassignment.From,
+		"status":              complaint.Status,
+	}
+
+	set := bson.M{
+		"responding_lecturer": reassignment.To,
+		"assignment":          assignment,
+		"updated_at":          reassignment.At,
+	}
 	update := bson.M{
-		"$set": bson.M{
-			"status":         newStatus,
-			"reason":         reason,
-			"lecturer_proof": lecturer_proof,
-		},
+		"$set":  set,
+		"$push": bson.M{"reassignments": reassignment},
+	}
+	if complaint.Status == StatusPending {
+		unset := bson.M{"reminded_at": "", "escalated": ""}
+		if dueAt := s.deadlines.dueAt(StatusPending, reassignment.At); dueAt != nil {
+			set["due_at"] = dueAt
+		} else {
+			unset["due_at"] = ""
+		}
+		update["$unset"] = unset
 	}
 
-	_, err = collection.UpdateOne(context.Background(), filter, update)
+	err = s.withEvent(func(ctx mongo.SessionContext) (Event, error) {
+		var updated Complaint
+		if err := collection.FindOneAndUpdate(ctx, filter, update, returnUpdated).Decode(&updated); err != nil {
+			return Event{}, err
+		}
+		event := newEvent(EventComplaintReassigned, updated)
+		event.Reassignment = &reassignment
+		return event, nil
+	})
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		complaint, err := s.GetComplaintByObjectId(objectID)
+		if err != nil {
+			return err
+		}
+		return reassignConflict(complaint, reassignment)
+	}
+	return err
+}
+
+func (s *mongoStore) RemindComplaint(id string, stage Status) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return err
 	}
-	return nil
-}
 
-func ChangeStatusToByHOD(id string) error {
-	collection := GetDBCollection("Complaints")
+	filter := bson.M{
+		"_id":         objectID,
+		"status":      stage,
+		"reminded_at": bson.M{"$exists": false},
+		"escalated":   bson.M{"$ne": true},
+	}
+	update := bson.M{"$set": bson.M{"reminded_at": time.Now()}}
 
+	return s.updateWithEvent(objectID, stage, filter, update, func(event *Event) {
+		event.Type = EventComplaintDueSoon
+	})
+}
+
+func (s *mongoStore) EscalateComplaint(id string, escalation Escalation) error {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return err
 	}
 
-	filter := bson.M{"_id": objectID}
-
+	filter := bson.M{
+		"_id":       objectID,
+		"status":    escalation.Stage,
+		"escalated": bson.M{"$ne": true},
+	}
 	update := bson.M{
-		"$set": bson.M{
-			"status": "Approved by HOD",
-		},
+		"$set":  bson.M{"escalated": true},
+		"$push": bson.M{"escalations": escalation},
+	}
+
+	return s.updateWithEvent(objectID, escalation.Stage, filter, update, func(event *Event) {
+		event.Type = EventComplaintEscalated
+		event.Escalation = &escalation
+	})
+}
+
+// updateWithEvent applies a deadline update to a complaint and records the event fill describes
+// with it. A complaint the filter no longer matches gets a deadlineConflict.
+func (s *mongoStore) updateWithEvent(id primitive.ObjectID, stage Status, filter, update bson.M, fill func(*Event)) error {
+	collection := s.GetDBCollection("Complaints")
+
+	err := s.withEvent(func(ctx mongo.SessionContext) (Event, error) {
+		var updated Complaint
+		if err := collection.FindOneAndUpdate(ctx, filter, update, returnUpdated).Decode(&updated); err != nil {
+			return Event{}, err
+		}
+		event := newEvent("", updated)
+		fill(&event)
+		return event, nil
+	})
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		complaint, err := s.GetComplaintByObjectId(id)
+		if err != nil {
+			return err
+		}
+		return deadlineConflict(complaint, stage)
+	}
+	return err
+}
+
+// AddAttachments adds files to a complaint that is still open
+func (s *mongoStore) AddAttachments(id string, attachments []Attachment) error {
+	update := bson.M{"$push": bson.M{"attachments": bson.M{"$each": attachments}}}
+	return s.updateOpenComplaint(id, bson.M{}, update)
+}
+
+// RemoveAttachment removes a file from a complaint that is still open
+func (s *mongoStore) RemoveAttachment(id string, attachmentID primitive.ObjectID) error {
+	filter := bson.M{"attachments._id": attachmentID}
+	update := bson.M{"$pull": bson.M{"attachments": bson.M{"_id": attachmentID}}}
+	return s.updateOpenComplaint(id, filter, update)
+}
+
+// updateOpenComplaint applies update to the complaint if it matches filter and is still open,
+// working out from the complaint as it stands why nothing matched otherwise
+func (s *mongoStore) updateOpenComplaint(id string, filter bson.M, update bson.M) error {
+	collection := s.GetDBCollection("Complaints")
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
 	}
-	_, err = collection.UpdateOne(context.Background(), filter, update)
+
+	filter["_id"] = objectID
+	filter["status"] = bson.M{"$in": OpenStatuses()}
+	result, err := collection.UpdateOne(context.Background(), filter, update)
 	if err != nil {
 		return err
 	}
-	return nil
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	complaint, err := s.GetComplaintByObjectId(objectID)
+	if err != nil {
+		return err
+	}
+	if complaint.Status.IsFinal() {
+		return closedConflict(complaint)
+	}
+	return ErrAttachmentNotFound
 }
 
-func GetStudentById(userID string) (Student, error) {
+func (s *mongoStore) GetStudentById(userID string) (Student, error) {
 	var student Student
-	collection := GetDBCollection("Students")
+	collection := s.GetDBCollection("Students")
 
 	err := collection.FindOne(context.Background(), bson.M{"matric_no": userID}).Decode(&student)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return Student{}, fmt.Errorf("Student not found")
+			return Student{}, ErrStudentNotFound
 		}
 		return Student{}, err
 	}
 	return student, nil
 }
 
-func GetStaffById(userID string) (Lecturer, error) {
+func (s *mongoStore) GetStaffById(userID string) (Lecturer, error) {
 	var lecturer Lecturer
-	collection := GetDBCollection("Lecturers")
+	collection := s.GetDBCollection("Lecturers")
 
 	err := collection.FindOne(context.Background(), bson.M{"staff_id": userID}).Decode(&lecturer)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return Lecturer{}, fmt.Errorf("Lecturer not found")
+			return Lecturer{}, ErrLecturerNotFound
 		}
 		return Lecturer{}, err
 	}
 	return lecturer, nil
 }
 
-func GetComplaintsByStatus(status string) ([]Complaint, error) {
-	collection := GetDBCollection("Complaints")
+// GetComplaintsByStatus returns the complaints currently in any of the given statuses
+func (s *mongoStore) GetComplaintsByStatus(query ComplaintQuery, statuses ...Status) (ComplaintPage, error) {
 	filter := bson.M{
-		"status": status,
+		"status": bson.M{"$in": statuses},
 	}
 
-	cursor, err := collection.Find(context.Background(), filter)
+	return s.findComplaints(filter, query)
+}
+
+func (s *mongoStore) GetComplaintsInHODQueue(query ComplaintQuery) (ComplaintPage, error) {
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"status": StatusApprovedByAdvisor},
+			bson.M{"escalated": true, "status": bson.M{"$in": OpenStatuses()}},
+		},
+	}
+
+	return s.findComplaints(filter, query)
+}
+
+func (s *mongoStore) GetComplaintsDueBy(t time.Time) ([]Complaint, error) {
+	collection := s.GetDBCollection("Complaints")
+
+	filter := bson.M{
+		"status":    bson.M{"$in": OpenStatuses()},
+		"escalated": bson.M{"$ne": true},
+		"due_at":    bson.M{"$lte": t},
+	}
+	cursor, err := collection.Find(context.Background(), filter, options.Find().SetSort(bson.D{{Key: "due_at", Value: 1}}))
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(context.Background())
 
-	var complaints []Complaint
-	for cursor.Next(context.Background()) {
-		var complaint Complaint
-		err := cursor.Decode(&complaint)
-		if err != nil {
-			return nil, err
-		}
-		complaints = append(complaints, complaint)
+	complaints := []Complaint{}
+	if err := cursor.All(context.Background(), &complaints); err != nil {
+		return nil, err
 	}
-
 	return complaints, nil
 }
 
-func GetComplaintsByCourseCode(id, courseCode string) ([]Complaint, error) {
-	collection := GetDBCollection("Complaints")
-
+func (s *mongoStore) GetComplaintsByCourseCode(id, courseCode string, query ComplaintQuery) (ComplaintPage, error) {
 	filter := bson.M{
 		"course_concerned":    courseCode,
 		"responding_lecturer": id,
 	}
 
-	cursor, err := collection.Find(context.Background(), filter)
+	return s.findComplaints(filter, query)
+}
+
+func (s *mongoStore) GetComplaintByCourseCode(id, courseCode string) (*Complaint, error) {
+	var complaint Complaint
+	collection := s.GetDBCollection("Complaints")
+
+	filter := bson.M{
+		"course_concerned":   courseCode,
+		"requesting_student": id,
+	}
+
+	err := collection.FindOne(context.Background(), filter).Decode(&complaint)
 	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
 		return nil, err
 	}
+	return &complaint, nil
+}
+
+func (s *mongoStore) ComplaintAlreadyExists(id, courseCode string) (bool, error) {
+	complaint, err := s.GetComplaintByCourseCode(id, courseCode)
+	if err != nil {
+		return false, err
+	}
+	return complaint != nil, nil
+}
+
+// findComplaints returns the page of complaints matching filter narrowed by query
+func (s *mongoStore) findComplaints(filter bson.M, query ComplaintQuery) (ComplaintPage, error) {
+	collection := s.GetDBCollection("Complaints")
+	filter = query.filter(filter)
+
+	total, err := collection.CountDocuments(context.Background(), filter)
+	if err != nil {
+		return ComplaintPage{}, err
+	}
+
+	cursor, err := collection.Find(context.Background(), filter, query.findOptions())
+	if err != nil {
+		return ComplaintPage{}, err
+	}
 	defer cursor.Close(context.Background())
 
 	var complaints []Complaint
@@ -342,49 +816,243 @@ func GetComplaintsByCourseCode(id, courseCode string) ([]Complaint, error) {
 		var complaint Complaint
 		err := cursor.Decode(&complaint)
 		if err != nil {
-			return nil, err
+			return ComplaintPage{}, err
 		}
 		complaints = append(complaints, complaint)
 	}
 
-	return complaints, nil
+	return query.newPage(complaints, total), nil
 }
 
-func GetComplaintByCourseCode(id, courseCode string) (*Complaint, error) {
-	var complaint Complaint
-	collection := GetDBCollection("Complaints")
+func (s *mongoStore) CreateNotification(notification Notification) error {
+	collection := s.GetDBCollection("Notifications")
 
-	filter := bson.M{
-		"course_concerned":   courseCode,
-		"requesting_student": id,
+	if notification.ID.IsZero() {
+		notification.ID = primitive.NewObjectID()
+	}
+	if notification.EventID.IsZero() {
+		_, err := collection.InsertOne(context.Background(), notification)
+		return err
 	}
 
-	err := collection.FindOne(context.Background(), filter).Decode(&complaint)
+	filter := bson.M{"event_id": notification.EventID, "user_id": notification.UserID, "kind": notification.Kind}
+	opts := options.Update().SetUpsert(true)
+	_, err := collection.UpdateOne(context.Background(), filter, bson.M{"$setOnInsert": notification}, opts)
+	return err
+}
+
+func (s *mongoStore) GetNotifications(userID string, unreadOnly bool, limit int) ([]Notification, error) {
+	collection := s.GetDBCollection("Notifications")
+
+	filter := bson.M{"user_id": userID}
+	if unreadOnly {
+		filter["read"] = false
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(int64(limit))
+
+	cursor, err := collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	notifications := []Notification{}
+	if err := cursor.All(context.Background(), &notifications); err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (s *mongoStore) CountUnreadNotifications(userID string) (int64, error) {
+	collection := s.GetDBCollection("Notifications")
+
+	return collection.CountDocuments(context.Background(), bson.M{"user_id": userID, "read": false})
+}
+
+func (s *mongoStore) MarkNotificationsRead(userID string, ids []primitive.ObjectID) (int64, error) {
+	collection := s.GetDBCollection("Notifications")
+
+	filter := bson.M{"user_id": userID, "read": false}
+	if len(ids) > 0 {
+		filter["_id"] = bson.M{"$in": ids}
+	}
+	update := bson.M{"$set": bson.M{"read": true, "read_at": time.Now()}}
+
+	result, err := collection.UpdateMany(context.Background(), filter, update)
 	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (s *mongoStore) AddComment(comment Comment) error {
+	if comment.ID.IsZero() {
+		comment.ID = primitive.NewObjectID()
+	}
+
+	return s.withEvent(func(ctx mongo.SessionContext) (Event, error) {
+		var complaint Complaint
+		err := s.GetDBCollection("Complaints").FindOne(ctx, bson.M{"_id": comment.ComplaintID}).Decode(&complaint)
 		if err == mongo.ErrNoDocuments {
-			return nil, nil
+			return Event{}, ErrComplaintNotFound
+		}
+		if err != nil {
+			return Event{}, err
+		}
+		if _, err := s.GetDBCollection("Comments").InsertOne(ctx, comment); err != nil {
+			return Event{}, err
 		}
+
+		event := newEvent(EventCommentAdded, complaint)
+		event.Comment = &comment
+		return event, nil
+	})
+}
+
+func (s *mongoStore) GetComments(complaintID primitive.ObjectID, role string) ([]Comment, error) {
+	collection := s.GetDBCollection("Comments")
+
+	filter := bson.M{"complaint_id": complaintID, "visible_to": role}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := collection.Find(context.Background(), filter, opts)
+	if err != nil {
 		return nil, err
 	}
-	return &complaint, nil
+	defer cursor.Close(context.Background())
+
+	comments := []Comment{}
+	if err := cursor.All(context.Background(), &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
 }
 
-func ComplaintAlreadyExists(id, courseCode string) (bool, error) {
-	var complaint Complaint
-	collection := GetDBCollection("Complaints")
+func (s *mongoStore) CreateWebhook(webhook Webhook) (string, error) {
+	collection := s.GetDBCollection("Webhooks")
 
-	filter := bson.M{
-		"course_concerned":   courseCode,
-		"requesting_student": id,
+	if webhook.ID.IsZero() {
+		webhook.ID = primitive.NewObjectID()
+	}
+	if _, err := collection.InsertOne(context.Background(), webhook); err != nil {
+		return "", err
 	}
+	return webhook.ID.Hex(), nil
+}
 
-	err := collection.FindOne(context.Background(), filter).Decode(&complaint)
+func (s *mongoStore) GetWebhooks() ([]Webhook, error) {
+	collection := s.GetDBCollection("Webhooks")
 
+	cursor, err := collection.Find(context.Background(), bson.M{})
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return false, nil
-		}
-		return false, err
+		return nil, err
 	}
-	return true, nil
+	defer cursor.Close(context.Background())
+
+	webhooks := []Webhook{}
+	if err := cursor.All(context.Background(), &webhooks); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (s *mongoStore) GetWebhook(id primitive.ObjectID) (Webhook, error) {
+	collection := s.GetDBCollection("Webhooks")
+
+	var webhook Webhook
+	err := collection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&webhook)
+	if err == mongo.ErrNoDocuments {
+		return webhook, ErrWebhookNotFound
+	}
+	return webhook, err
+}
+
+func (s *mongoStore) DeleteWebhook(id primitive.ObjectID) error {
+	collection := s.GetDBCollection("Webhooks")
+
+	result, err := collection.DeleteOne(context.Background(), bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (s *mongoStore) RecordDelivery(delivery WebhookDelivery) error {
+	collection := s.GetDBCollection("WebhookDeliveries")
+
+	if delivery.ID.IsZero() {
+		delivery.ID = primitive.NewObjectID()
+	}
+	_, err := collection.InsertOne(context.Background(), delivery)
+	return err
+}
+
+func (s *mongoStore) GetDeliveries(webhookID primitive.ObjectID, limit int) ([]WebhookDelivery, error) {
+	collection := s.GetDBCollection("WebhookDeliveries")
+
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := collection.Find(context.Background(), bson.M{"webhook_id": webhookID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	deliveries := []WebhookDelivery{}
+	if err := cursor.All(context.Background(), &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (s *mongoStore) QueueJob(job Job) error {
+	collection := s.GetDBCollection("Jobs")
+
+	filter := bson.M{"queue": job.Queue, "key": job.Key}
+	opts := options.Update().SetUpsert(true)
+	_, err := collection.UpdateOne(context.Background(), filter, bson.M{"$setOnInsert": job}, opts)
+	return err
+}
+
+func (s *mongoStore) ClaimJob(queue string, lease time.Duration) (Job, bool, error) {
+	collection := s.GetDBCollection("Jobs")
+
+	now := time.Now()
+	filter := bson.M{
+		"queue":         queue,
+		"done_at":       bson.M{"$exists": false},
+		"run_at":        bson.M{"$lte": now},
+		"claimed_until": bson.M{"$lte": now},
+	}
+	update := bson.M{
+		"$set": bson.M{"claimed_until": now.Add(lease)},
+		"$inc": bson.M{"attempt": 1},
+	}
+	opts := options.FindOneAndUpdate().SetSort(bson.D{{Key: "run_at", Value: 1}}).SetReturnDocument(options.After)
+
+	var job Job
+	err := collection.FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return Job{}, false, nil
+	}
+	if err != nil {
+		return Job{}, false, err
+	}
+	return job, true, nil
+}
+
+func (s *mongoStore) RetryJob(id primitive.ObjectID, runAt time.Time) error {
+	collection := s.GetDBCollection("Jobs")
+
+	update := bson.M{"$set": bson.M{"run_at": runAt, "claimed_until": time.Time{}}}
+	_, err := collection.UpdateOne(context.Background(), bson.M{"_id": id}, update)
+	return err
+}
+
+func (s *mongoStore) CompleteJob(id primitive.ObjectID) error {
+	collection := s.GetDBCollection("Jobs")
+
+	_, err := collection.UpdateOne(context.Background(), bson.M{"_id": id}, bson.M{"$set": bson.M{"done_at": time.Now()}})
+	return err
 }
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Deadlines returns when a complaint that reached stage at since is due to move on, or nil for a
+// stage without a deadline
+type Deadlines func(stage Status, since time.Time) *time.Time
+
+// SetDeadlines sets how the store works out the due date of a complaint each time it is filed,
+// moves to another stage or is reassigned. Without it complaints have no due date.
+func (s *Store) SetDeadlines(deadlines Deadlines) {
+	*s.deadlines = deadlines
+}
+
+// dueAt returns when a complaint that reached stage at since is due, nil if it never is
+func (d *Deadlines) dueAt(stage Status, since time.Time) *time.Time {
+	if d == nil || *d == nil || stage.IsFinal() {
+		return nil
+	}
+	return (*d)(stage, since)
+}
@@ -0,0 +1,183 @@
+package models
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EventType names something that happened to a complaint
+type EventType string
+
+const (
+	EventComplaintCreated    EventType = "complaint.created"
+	EventComplaintReassigned EventType = "complaint.reassigned"
+	EventStatusChanged       EventType = "complaint.status_changed"
+	EventComplaintDueSoon    EventType = "complaint.due_soon"
+	EventComplaintEscalated  EventType = "complaint.escalated"
+	EventCommentAdded        EventType = "complaint.comment_added"
+)
+
+// EventTypes lists every type of event the store records
+var EventTypes = []EventType{
+	EventComplaintCreated,
+	EventComplaintReassigned,
+	EventStatusChanged,
+	EventComplaintDueSoon,
+	EventComplaintEscalated,
+	EventCommentAdded,
+}
+
+// Event records a change the store made to a complaint, with the complaint as it stood
+// afterwards
+type Event struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	Type      EventType          `json:"type" bson:"type"`
+	Complaint Complaint          `json:"complaint" bson:"complaint"`
+	// Change is the status change of an EventStatusChanged event
+	Change *StatusChange `json:"change,omitempty" bson:"change,omitempty"`
+	// Reassignment is the move of an EventComplaintReassigned event
+	Reassignment *Reassignment `json:"reassignment,omitempty" bson:"reassignment,omitempty"`
+	// Escalation is the escalation of an EventComplaintEscalated event
+	Escalation *Escalation `json:"escalation,omitempty" bson:"escalation,omitempty"`
+	// Comment is the comment of an EventCommentAdded event
+	Comment *Comment  `json:"comment,omitempty" bson:"comment,omitempty"`
+	At      time.Time `json:"at" bson:"at"`
+}
+
+func newEvent(eventType EventType, complaint Complaint) Event {
+	return Event{
+		ID:        primitive.NewObjectID(),
+		Type:      eventType,
+		Complaint: complaint,
+		At:        time.Now(),
+	}
+}
+
+// Subscribe registers fn to be called with every event the store records, on a goroutine of
+// the dispatcher's, so fn may read from the store but should hand slow work off, for instance by
+// queueing a Job. An event is only marked dispatched once every subscriber returned nil for it;
+// if one fails, the event is delivered to them all again a while later. Delivery is therefore
+// at least once, also when the process stops half way, and fn should tolerate seeing the same
+// event ID twice. Events are delivered in the order they were recorded, except for retries.
+func (s *Store) Subscribe(fn func(Event) error) {
+	s.events.subscribe(fn)
+}
+
+// Watch starts passing every event recorded from now on to fn, including those recorded by other
+// API processes sharing the database. Unlike Subscribe, every process sees every event, but at
+// most once and only while it runs, which suits live updates such as the event streams of open
+// dashboards. fn is called on a goroutine of its own and must not block. Call Watch once.
+func (s *Store) Watch(fn func(Event)) {
+	go s.events.outbox.watchEvents(fn)
+}
+
+// StartDispatcher starts passing the events recorded in the outbox to the subscribers. Call it
+// once every subscriber is registered, since an event is only delivered once.
+func (s *Store) StartDispatcher() {
+	go s.events.deliver()
+}
+
+const (
+	// outboxPoll is how often the outbox is checked for events no wake-up was sent for, such as
+	// those left by a crash or recorded by another API process
+	outboxPoll = 5 * time.Second
+	// outboxLease is how long a dispatcher has to deliver an event it claimed before another
+	// may claim it, and so how long a failed event waits to be delivered again
+	outboxLease = time.Minute
+)
+
+// outboxEntry is an event in the outbox. The store records it together with the change it
+// describes, and deletes it once every subscriber has handled it.
+type outboxEntry struct {
+	Event        `bson:",inline"`
+	ClaimedUntil time.Time `bson:"claimed_until"`
+}
+
+// outbox is the store side of the event dispatcher
+type outbox interface {
+	// claimEvent takes the oldest event in the outbox that is not claimed, for lease, and
+	// reports whether there was one
+	claimEvent(lease time.Duration) (Event, bool, error)
+	// markDispatched deletes an event every subscriber has handled from the outbox
+	markDispatched(id primitive.ObjectID) error
+	// watchEvents calls fn with every event recorded from now on, by any process, and never
+	// returns
+	watchEvents(fn func(Event))
+}
+
+// eventBus passes the events recorded in a store's outbox to its subscribers
+type eventBus struct {
+	outbox      outbox
+	mu          sync.Mutex
+	subscribers []func(Event) error
+	wake        chan struct{}
+}
+
+func newEventBus(outbox outbox) *eventBus {
+	return &eventBus{outbox: outbox, wake: make(chan struct{}, 1)}
+}
+
+func (b *eventBus) subscribe(fn func(Event) error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, fn)
+}
+
+// notify tells the dispatcher an event was recorded. It never waits, so the store can call it
+// while it holds its own locks.
+func (b *eventBus) notify() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (b *eventBus) deliver() {
+	poll := time.NewTicker(outboxPoll)
+	defer poll.Stop()
+
+	for {
+		b.dispatch()
+		select {
+		case <-b.wake:
+		case <-poll.C:
+		}
+	}
+}
+
+// dispatch delivers the waiting events until the outbox is empty or cannot be read. An event a
+// subscriber failed to handle stays claimed, so it is claimed again once its lease runs out.
+func (b *eventBus) dispatch() {
+	b.mu.Lock()
+	subscribers := b.subscribers
+	b.mu.Unlock()
+
+	for {
+		event, ok, err := b.outbox.claimEvent(outboxLease)
+		if err != nil {
+			log.Println("Unable to read the event outbox:", err)
+			return
+		}
+		if !ok {
+			return
+		}
+
+		handled := true
+		for _, fn := range subscribers {
+			if err := fn(event); err != nil {
+				log.Printf("Unable to handle event %s, it will be retried: %v", event.ID.Hex(), err)
+				handled = false
+			}
+		}
+		if !handled {
+			continue
+		}
+		if err := b.outbox.markDispatched(event.ID); err != nil {
+			log.Println("Unable to mark event dispatched:", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Job is a piece of work kept in the store until it is done, so it survives a restart. Webhook
+// deliveries and notification emails are queued as jobs when their event is dispatched.
+type Job struct {
+	ID primitive.ObjectID `bson:"_id"`
+	// Queue names the worker the job is for, such as "webhook" or "email"
+	Queue string `bson:"queue"`
+	// Key identifies the work within its queue, so queueing it a second time does nothing
+	Key     string `bson:"key"`
+	Payload []byte `bson:"payload"`
+	// Attempt counts the times the job has been claimed, the current one included
+	Attempt      int        `bson:"attempt"`
+	RunAt        time.Time  `bson:"run_at"`
+	ClaimedUntil time.Time  `bson:"claimed_until"`
+	DoneAt       *time.Time `bson:"done_at,omitempty"`
+	CreatedAt    time.Time  `bson:"created_at"`
+}
+
+// NewJob returns a job for queue that can run at once
+func NewJob(queue, key string, payload []byte) Job {
+	now := time.Now()
+	return Job{
+		ID:        primitive.NewObjectID(),
+		Queue:     queue,
+		Key:       key,
+		Payload:   payload,
+		RunAt:     now,
+		CreatedAt: now,
+	}
+}
+
+// JobStore keeps the jobs waiting to be worked on
+type JobStore interface {
+	// QueueJob saves a job, unless one with the same queue and key is still kept
+	QueueJob(job Job) error
+	// ClaimJob takes the job of queue that has been due longest and is not claimed, for lease,
+	// and reports whether there was one
+	ClaimJob(queue string, lease time.Duration) (Job, bool, error)
+	// RetryJob releases a claimed job to run again at runAt
+	RetryJob(id primitive.ObjectID, runAt time.Time) error
+	// CompleteJob marks a job done, whether it succeeded or was given up on. Done jobs are
+	// deleted after jobRetention.
+	CompleteJob(id primitive.ObjectID) error
+}
+
+// jobRetention is how long a done job is kept, so the same work queued again in that time, as
+// when an event is handled a second time, is not done twice
+const jobRetention = 7 * 24 * time.Hour
+
+const (
+	// jobPoll is how often a JobWorker looks for jobs that came due without a wake-up
+	jobPoll = time.Second
+	// jobLease is how long a worker has to finish a job it claimed before it is run again
+	jobLease = time.Minute
+)
+
+// JobWorker claims the jobs of a queue as they come due and runs them. Its work function must
+// complete or retry each job; one that does neither, because the process stopped, is run again
+// once its lease runs out.
+type JobWorker struct {
+	jobs  JobStore
+	queue string
+	work  func(Job)
+	wake  chan struct{}
+}
+
+// NewJobWorker returns a JobWorker running work on the jobs of queue
+func NewJobWorker(jobs JobStore, queue string, work func(Job)) *JobWorker {
+	return &JobWorker{jobs: jobs, queue: queue, work: work, wake: make(chan struct{}, 1)}
+}
+
+// Start runs jobs on workers goroutines in the background
+func (w *JobWorker) Start(workers int) {
+	claimed := make(chan Job)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range claimed {
+				w.work(job)
+			}
+		}()
+	}
+
+	go func() {
+		poll := time.NewTicker(jobPoll)
+		defer poll.Stop()
+		for {
+			for {
+				job, ok, err := w.jobs.ClaimJob(w.queue, jobLease)
+				if err != nil {
+					log.Printf("Unable to claim %s job: %v", w.queue, err)
+				}
+				if !ok {
+					break
+				}
+				claimed <- job
+			}
+			select {
+			case <-w.wake:
+			case <-poll.C:
+			}
+		}
+	}()
+}
+
+// Wake tells the worker a job was queued, so it need not wait for the next poll. It never waits.
+func (w *JobWorker) Wake() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// maxBackoff is the longest Backoff waits, however many attempts have failed
+const maxBackoff = 24 * time.Hour
+
+// Backoff returns how long to wait before the next attempt of a job that failed its attempt-th
+// time, doubling from initial up to maxBackoff
+func Backoff(initial time.Duration, attempt int) time.Duration {
+	wait := initial
+	// doubling stops at the cap, so it cannot overflow
+	for i := 1; i < attempt && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxBackoff)
+}
@@ -0,0 +1,957 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryStore implements every repository in Store with in-process slices. Data is
+// lost when the process exits, which is what tests and local development want.
+type memoryStore struct {
+	mu            sync.RWMutex
+	users         []User
+	complaints    []Complaint
+	courses       []Course
+	students      []Student
+	lecturers     []Lecturer
+	departments   []Department
+	advisors      []Advisor
+	notifications []Notification
+	webhooks      []Webhook
+	deliveries    []WebhookDelivery
+	comments      []Comment
+	jobs          []Job
+	outbox        []outboxEntry
+	// recorded holds the events recorded since the watcher last took them, when one is watching
+	recorded  []Event
+	watching  bool
+	watchWake chan struct{}
+	events    *eventBus
+	deadlines *Deadlines
+}
+
+// MemorySeed is the reference data an in-memory store can be started with
+type MemorySeed struct {
+	Users       []User       `json:"users"`
+	Courses     []Course     `json:"courses"`
+	Students    []Student    `json:"students"`
+	Lecturers   []Lecturer   `json:"lecturers"`
+	Departments []Department `json:"departments"`
+	Advisors    []Advisor    `json:"advisors"`
+}
+
+// NewMemoryStore returns a Store that keeps everything in memory, starting from seed
+func NewMemoryStore(seed MemorySeed) *Store {
+	s := &memoryStore{deadlines: new(Deadlines), watchWake: make(chan struct{}, 1)}
+	s.events = newEventBus(s)
+	for _, user := range seed.Users {
+		if user.ID.IsZero() {
+			user.ID = primitive.NewObjectID()
+		}
+		s.users = append(s.users, user)
+	}
+	for _, course := range seed.Courses {
+		if course.ID.IsZero() {
+			course.ID = primitive.NewObjectID()
+		}
+		s.courses = append(s.courses, course)
+	}
+	for _, student := range seed.Students {
+		if student.ID.IsZero() {
+			student.ID = primitive.NewObjectID()
+		}
+		s.students = append(s.students, student)
+	}
+	for _, lecturer := range seed.Lecturers {
+		if lecturer.ID.IsZero() {
+			lecturer.ID = primitive.NewObjectID()
+		}
+		s.lecturers = append(s.lecturers, lecturer)
+	}
+	for _, department := range seed.Departments {
+		if department.ID.IsZero() {
+			department.ID = primitive.NewObjectID()
+		}
+		s.departments = append(s.departments, department)
+	}
+	for _, advisor := range seed.Advisors {
+		if advisor.ID.IsZero() {
+			advisor.ID = primitive.NewObjectID()
+		}
+		s.advisors = append(s.advisors, advisor)
+	}
+
+	return &Store{
+		Users:         s,
+		Complaints:    s,
+		Courses:       s,
+		Students:      s,
+		Lecturers:     s,
+		Departments:   s,
+		Advisors:      s,
+		Notifications: s,
+		Webhooks:      s,
+		Comments:      s,
+		Jobs:          s,
+		events:        s.events,
+		deadlines:     s.deadlines,
+	}
+}
+
+// LoadMemorySeed reads a MemorySeed from a JSON file. An empty path gives an empty seed.
+func LoadMemorySeed(path string) (MemorySeed, error) {
+	var seed MemorySeed
+	if path == "" {
+		return seed, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return seed, fmt.Errorf("error reading seed file: %w", err)
+	}
+	if err := json.Unmarshal(data, &seed); err != nil {
+		return seed, fmt.Errorf("error parsing seed file: %w", err)
+	}
+	return seed, nil
+}
+
+func (s *memoryStore) Register(user User) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	s.users = append(s.users, user)
+
+	return user.ID.Hex(), nil
+}
+
+func (s *memoryStore) GetUserByObjectID(id primitive.ObjectID) (User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, user := range s.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return User{}, ErrUserNotFound
+}
+
+func (s *memoryStore) GetUserByUserID(userID string) (User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, user := range s.users {
+		if user.UserID == userID {
+			return user, nil
+		}
+	}
+	return User{}, ErrUserNotFound
+}
+
+func (s *memoryStore) GetUsersByRole(role string) ([]User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var users []User
+	for _, user := range s.users {
+		if user.Role == role {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (s *memoryStore) CreateNewComplaint(complaint Complaint) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if complaint.ID.IsZero() {
+		complaint.ID = primitive.NewObjectID()
+	}
+	complaint.DueAt = s.deadlines.dueAt(complaint.Status, complaint.CreatedAt)
+	s.complaints = append(s.complaints, cloneComplaint(complaint))
+	s.record(newEvent(EventComplaintCreated, complaint))
+
+	return complaint.ID.Hex(), nil
+}
+
+func (s *memoryStore) GetCourseByCourseCode(courseCode string) (Course, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, course := range s.courses {
+		if course.CourseCode == courseCode {
+			return course, nil
+		}
+	}
+	return Course{}, ErrCourseNotFound
+}
+
+func (s *memoryStore) GetMatricNo(email string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, student := range s.students {
+		if student.Email == email {
+			return student.MatricNo, nil
+		}
+	}
+	return "", ErrStudentNotFound
+}
+
+func (s *memoryStore) GetComplaintByObjectId(id primitive.ObjectID) (Complaint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if i := s.complaintIndex(id); i >= 0 {
+		return cloneComplaint(s.complaints[i]), nil
+	}
+	return Complaint{}, ErrComplaintNotFound
+}
+
+func (s *memoryStore) GetComplaintsByStaffId(id string, query ComplaintQuery) (ComplaintPage, error) {
+	return s.pageComplaints(query, func(c Complaint) bool {
+		return c.RespondingLecturer == id
+	}), nil
+}
+
+func (s *memoryStore) CountOpenComplaintsByStaffId(id string) (int64, error) {
+	complaints := s.findComplaints(func(c Complaint) bool {
+		return c.RespondingLecturer == id && !c.Status.IsFinal()
+	})
+	return int64(len(complaints)), nil
+}
+
+func (s *memoryStore) GetComplaintsByStudentId(id string, query ComplaintQuery) (ComplaintPage, error) {
+	return s.pageComplaints(query, func(c Complaint) bool {
+		return c.RequestingStudent == id
+	}), nil
+}
+
+func (s *memoryStore) GetComplaintsByStatus(query ComplaintQuery, statuses ...Status) (ComplaintPage, error) {
+	return s.pageComplaints(query, func(c Complaint) bool {
+		for _, status := range statuses {
+			if c.Status == status {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (s *memoryStore) GetComplaintsInHODQueue(query ComplaintQuery) (ComplaintPage, error) {
+	return s.pageComplaints(query, func(c Complaint) bool {
+		return c.Status == StatusApprovedByAdvisor || (c.Escalated && !c.Status.IsFinal())
+	}), nil
+}
+
+func (s *memoryStore) GetComplaintsDueBy(t time.Time) ([]Complaint, error) {
+	return s.findComplaints(func(c Complaint) bool {
+		return c.DueAt != nil && !c.DueAt.After(t) && !c.Escalated && !c.Status.IsFinal()
+	}), nil
+}
+
+func (s *memoryStore) GetComplaintsByCourseCode(id, courseCode string, query ComplaintQuery) (ComplaintPage, error) {
+	return s.pageComplaints(query, func(c Complaint) bool {
+		return c.CourseConcerned == courseCode && c.RespondingLecturer == id
+	}), nil
+}
+
+func (s *memoryStore) GetComplaintByCourseCode(id, courseCode string) (*Complaint, error) {
+	complaints := s.findComplaints(func(c Complaint) bool {
+		return c.CourseConcerned == courseCode && c.RequestingStudent == id
+	})
+	if len(complaints) == 0 {
+		return nil, nil
+	}
+	return &complaints[0], nil
+}
+
+func (s *memoryStore) ComplaintAlreadyExists(id, courseCode string) (bool, error) {
+	complaint, err := s.GetComplaintByCourseCode(id, courseCode)
+	if err != nil {
+		return false, err
+	}
+	return complaint != nil, nil
+}
+
+func (s *memoryStore) ChangeComplaintStatus(id string, newStatus Status, actor Actor) error {
+	return s.transitionComplaint(id, StatusChange{To: newStatus, Actor: actor}, nil)
+}
+
+func (s *memoryStore) ChangeComplaintStatusLecturer(id string, newStatus Status, reason string, proofs []Attachment, actor Actor) error {
+	change := lecturerChange(newStatus, reason, proofs, actor)
+	return s.transitionComplaint(id, change, func(c *Complaint) {
+		c.Reason = reason
+		c.LecturerProof = change.Attachment
+	})
+}
+
+func (s *memoryStore) DeclineComplaint(id string, reason string, proofs []Attachment, actor Actor) error {
+	change, err := declineChange(reason, proofs, actor)
+	if err != nil {
+		return err
+	}
+	return s.transitionComplaint(id, change, nil)
+}
+
+func (s *memoryStore) ChangeStatusToByHOD(id string, actor Actor) error {
+	return s.ChangeComplaintStatus(id, StatusApprovedByHOD, actor)
+}
+
+// transitionComplaint is the in-memory counterpart of mongoStore.transitionComplaint; holding
+// the write lock makes the check and the update atomic
+func (s *memoryStore) transitionComplaint(id string, change StatusChange, set func(*Complaint)) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.complaintIndex(objectID)
+	if i < 0 {
+		return ErrComplaintNotFound
+	}
+	complaint := &s.complaints[i]
+
+	change, err = prepareTransition(*complaint, change)
+	if err != nil {
+		return err
+	}
+
+	if set != nil {
+		set(complaint)
+	}
+	complaint.Status = change.To
+	complaint.UpdatedAt = change.At
+	complaint.DueAt = s.deadlines.dueAt(change.To, change.At)
+	complaint.RemindedAt = nil
+	complaint.Escalated = false
+	if decline := declineFor(change); decline != nil {
+		complaint.Decline = decline
+	}
+	complaint.Attachments = append(complaint.Attachments, change.files...)
+	change.files = nil
+	complaint.History = append(complaint.History, change)
+
+	event := newEvent(EventStatusChanged, *complaint)
+	event.Change = &change
+	s.record(event)
+	return nil
+}
+
+func (s *memoryStore) GetStudentsByProgram(program string, levels []int) ([]Student, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var students []Student
+	for _, student := range s.students {
+		if student.Program != program {
+			continue
+		}
+		if len(levels) > 0 && !containsInt(levels, student.Level) {
+			continue
+		}
+		students = append(students, student)
+	}
+	return students, nil
+}
+
+func (s *memoryStore) GetAdvisorsByUserID(userID string) ([]Advisor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var advisors []Advisor
+	for _, advisor := range s.advisors {
+		if advisor.UserID == userID {
+			advisors = append(advisors, advisor)
+		}
+	}
+	return advisors, nil
+}
+
+func (s *memoryStore) GetAdvisorsForStudent(student Student) ([]Advisor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var advisors []Advisor
+	for _, advisor := range s.advisors {
+		if advisor.Program != student.Program {
+			continue
+		}
+		if len(advisor.Levels) > 0 && !containsInt(advisor.Levels, student.Level) {
+			continue
+		}
+		advisors = append(advisors, advisor)
+	}
+	return advisors, nil
+}
+
+func (s *memoryStore) GetDepartmentsByHOD(userID string) ([]Department, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var departments []Department
+	for _, department := range s.departments {
+		for _, hod := range department.HODs {
+			if hod == userID {
+				departments = append(departments, department)
+				break
+			}
+		}
+	}
+	return departments, nil
+}
+
+func (s *memoryStore) GetDepartmentByCourseCode(courseCode string) (Department, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, department := range s.departments {
+		for _, course := range department.Courses {
+			if course == courseCode {
+				return department, nil
+			}
+		}
+	}
+	return Department{}, ErrDepartmentNotFound
+}
+
+func (s *memoryStore) ReassignComplaint(id string, reassignment Reassignment, assignment Assignment) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.complaintIndex(objectID)
+	if i < 0 {
+		return ErrComplaintNotFound
+	}
+	complaint := &s.complaints[i]
+
+	if complaint.Status.IsFinal() || complaint.RespondingLecturer != reassignment.From {
+		return reassignConflict(*complaint, reassignment)
+	}
+
+	complaint.RespondingLecturer = reassignment.To
+	complaint.Assignment = &assignment
+	complaint.UpdatedAt = reassignment.At
+	complaint.Reassignments = append(complaint.Reassignments, reassignment)
+	if complaint.Status == StatusPending {
+		complaint.DueAt = s.deadlines.dueAt(StatusPending, reassignment.At)
+		complaint.RemindedAt = nil
+		complaint.Escalated = false
+	}
+
+	event := newEvent(EventComplaintReassigned, *complaint)
+	event.Reassignment = &reassignment
+	s.record(event)
+	return nil
+}
+
+func (s *memoryStore) RemindComplaint(id string, stage Status) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.complaintIndex(objectID)
+	if i < 0 {
+		return ErrComplaintNotFound
+	}
+	complaint := &s.complaints[i]
+
+	if complaint.Status != stage || complaint.RemindedAt != nil || complaint.Escalated {
+		return deadlineConflict(*complaint, stage)
+	}
+
+	now := time.Now()
+	complaint.RemindedAt = &now
+	s.record(newEvent(EventComplaintDueSoon, *complaint))
+	return nil
+}
+
+func (s *memoryStore) EscalateComplaint(id string, escalation Escalation) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.complaintIndex(objectID)
+	if i < 0 {
+		return ErrComplaintNotFound
+	}
+	complaint := &s.complaints[i]
+
+	if complaint.Status != escalation.Stage || complaint.Escalated {
+		return deadlineConflict(*complaint, escalation.Stage)
+	}
+
+	complaint.Escalated = true
+	complaint.Escalations = append(complaint.Escalations, escalation)
+
+	event := newEvent(EventComplaintEscalated, *complaint)
+	event.Escalation = &escalation
+	s.record(event)
+	return nil
+}
+
+func (s *memoryStore) AddAttachments(id string, attachments []Attachment) error {
+	return s.updateOpenComplaint(id, func(c *Complaint) error {
+		c.Attachments = append(c.Attachments, attachments...)
+		return nil
+	})
+}
+
+func (s *memoryStore) RemoveAttachment(id string, attachmentID primitive.ObjectID) error {
+	return s.updateOpenComplaint(id, func(c *Complaint) error {
+		for i, attachment := range c.Attachments {
+			if attachment.ID == attachmentID {
+				c.Attachments = append(c.Attachments[:i:i], c.Attachments[i+1:]...)
+				return nil
+			}
+		}
+		return ErrAttachmentNotFound
+	})
+}
+
+// updateOpenComplaint is the in-memory counterpart of mongoStore.updateOpenComplaint
+func (s *memoryStore) updateOpenComplaint(id string, update func(*Complaint) error) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.complaintIndex(objectID)
+	if i < 0 {
+		return ErrComplaintNotFound
+	}
+	if s.complaints[i].Status.IsFinal() {
+		return closedConflict(s.complaints[i])
+	}
+	return update(&s.complaints[i])
+}
+
+func (s *memoryStore) GetStudentById(userID string) (Student, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, student := range s.students {
+		if student.MatricNo == userID {
+			return student, nil
+		}
+	}
+	return Student{}, ErrStudentNotFound
+}
+
+func (s *memoryStore) GetStaffById(userID string) (Lecturer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, lecturer := range s.lecturers {
+		if lecturer.StaffID == userID {
+			return lecturer, nil
+		}
+	}
+	return Lecturer{}, ErrLecturerNotFound
+}
+
+func (s *memoryStore) CreateNotification(notification Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if notification.ID.IsZero() {
+		notification.ID = primitive.NewObjectID()
+	}
+	if !notification.EventID.IsZero() {
+		for _, existing := range s.notifications {
+			if existing.EventID == notification.EventID && existing.UserID == notification.UserID && existing.Kind == notification.Kind {
+				return nil
+			}
+		}
+	}
+	s.notifications = append(s.notifications, notification)
+	return nil
+}
+
+func (s *memoryStore) GetNotifications(userID string, unreadOnly bool, limit int) ([]Notification, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	notifications := []Notification{}
+	for i := len(s.notifications) - 1; i >= 0 && len(notifications) < limit; i-- {
+		notification := s.notifications[i]
+		if notification.UserID != userID || (unreadOnly && notification.Read) {
+			continue
+		}
+		notifications = append(notifications, notification)
+	}
+	return notifications, nil
+}
+
+func (s *memoryStore) CountUnreadNotifications(userID string) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var count int64
+	for _, notification := range s.notifications {
+		if notification.UserID == userID && !notification.Read {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *memoryStore) MarkNotificationsRead(userID string, ids []primitive.ObjectID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var count int64
+	for i := range s.notifications {
+		notification := &s.notifications[i]
+		if notification.UserID != userID || notification.Read {
+			continue
+		}
+		if len(ids) > 0 && !containsID(ids, notification.ID) {
+			continue
+		}
+		notification.Read = true
+		notification.ReadAt = &now
+		count++
+	}
+	return count, nil
+}
+
+func (s *memoryStore) AddComment(comment Comment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.complaintIndex(comment.ComplaintID)
+	if i < 0 {
+		return ErrComplaintNotFound
+	}
+
+	if comment.ID.IsZero() {
+		comment.ID = primitive.NewObjectID()
+	}
+	comment.VisibleTo = append([]string(nil), comment.VisibleTo...)
+	comment.Attachments = append([]Attachment(nil), comment.Attachments...)
+	s.comments = append(s.comments, comment)
+
+	event := newEvent(EventCommentAdded, s.complaints[i])
+	event.Comment = &comment
+	s.record(event)
+	return nil
+}
+
+func (s *memoryStore) GetComments(complaintID primitive.ObjectID, role string) ([]Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	comments := []Comment{}
+	for _, comment := range s.comments {
+		if comment.ComplaintID == complaintID && contains(comment.VisibleTo, role) {
+			comments = append(comments, comment)
+		}
+	}
+	return comments, nil
+}
+
+func (s *memoryStore) CreateWebhook(webhook Webhook) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if webhook.ID.IsZero() {
+		webhook.ID = primitive.NewObjectID()
+	}
+	s.webhooks = append(s.webhooks, webhook)
+	return webhook.ID.Hex(), nil
+}
+
+func (s *memoryStore) GetWebhooks() ([]Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]Webhook{}, s.webhooks...), nil
+}
+
+func (s *memoryStore) GetWebhook(id primitive.ObjectID) (Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, webhook := range s.webhooks {
+		if webhook.ID == id {
+			return webhook, nil
+		}
+	}
+	return Webhook{}, ErrWebhookNotFound
+}
+
+func (s *memoryStore) DeleteWebhook(id primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, webhook := range s.webhooks {
+		if webhook.ID == id {
+			s.webhooks = append(s.webhooks[:i], s.webhooks[i+1:]...)
+			return nil
+		}
+	}
+	return ErrWebhookNotFound
+}
+
+func (s *memoryStore) RecordDelivery(delivery WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if delivery.ID.IsZero() {
+		delivery.ID = primitive.NewObjectID()
+	}
+	s.deliveries = append(s.deliveries, delivery)
+	return nil
+}
+
+func (s *memoryStore) GetDeliveries(webhookID primitive.ObjectID, limit int) ([]WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	deliveries := []WebhookDelivery{}
+	for i := len(s.deliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		if s.deliveries[i].WebhookID == webhookID {
+			deliveries = append(deliveries, s.deliveries[i])
+		}
+	}
+	return deliveries, nil
+}
+
+// record adds an event to the outbox. The caller must hold s.mu, so the event is recorded with
+// the change it describes; the complaint is copied, so the caller may keep changing its own.
+func (s *memoryStore) record(event Event) {
+	event.Complaint = cloneComplaint(event.Complaint)
+	s.outbox = append(s.outbox, outboxEntry{Event: event})
+	s.events.notify()
+
+	if s.watching {
+		s.recorded = append(s.recorded, event)
+		select {
+		case s.watchWake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// watchEvents passes on the events record collects. They are taken in batches so fn is never
+// called with s.mu held.
+func (s *memoryStore) watchEvents(fn func(Event)) {
+	s.mu.Lock()
+	s.watching = true
+	s.mu.Unlock()
+
+	for range s.watchWake {
+		s.mu.Lock()
+		recorded := s.recorded
+		s.recorded = nil
+		s.mu.Unlock()
+
+		for _, event := range recorded {
+			fn(event)
+		}
+	}
+}
+
+func (s *memoryStore) claimEvent(lease time.Duration) (Event, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for i := range s.outbox {
+		entry := &s.outbox[i]
+		if entry.ClaimedUntil.After(now) {
+			continue
+		}
+		entry.ClaimedUntil = now.Add(lease)
+		return entry.Event, true, nil
+	}
+	return Event{}, false, nil
+}
+
+func (s *memoryStore) markDispatched(id primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, entry := range s.outbox {
+		if entry.ID == id {
+			s.outbox = append(s.outbox[:i], s.outbox[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// complaintIndex returns the position of a complaint in s.complaints, or -1. The caller must hold s.mu.
+func (s *memoryStore) complaintIndex(id primitive.ObjectID) int {
+	for i, complaint := range s.complaints {
+		if complaint.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// findComplaints returns copies of every complaint for which match returns true
+func (s *memoryStore) findComplaints(match func(Complaint) bool) []Complaint {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var complaints []Complaint
+	for _, complaint := range s.complaints {
+		if match(complaint) {
+			complaints = append(complaints, cloneComplaint(complaint))
+		}
+	}
+	return complaints
+}
+
+// pageComplaints returns the page of complaints for which match returns true narrowed by query
+func (s *memoryStore) pageComplaints(query ComplaintQuery, match func(Complaint) bool) ComplaintPage {
+	complaints := s.findComplaints(func(c Complaint) bool {
+		return match(c) && query.matches(c)
+	})
+	return query.apply(complaints)
+}
+
+// cloneComplaint copies a complaint so callers cannot change the stored one through shared slices or pointers
+func cloneComplaint(c Complaint) Complaint {
+	c.History = append([]StatusChange(nil), c.History...)
+	c.Reassignments = append([]Reassignment(nil), c.Reassignments...)
+	c.Attachments = append([]Attachment(nil), c.Attachments...)
+	c.Escalations = append([]Escalation(nil), c.Escalations...)
+	if c.Decline != nil {
+		decline := *c.Decline
+		c.Decline = &decline
+	}
+	if c.Assignment != nil {
+		assignment := *c.Assignment
+		c.Assignment = &assignment
+	}
+	return c
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *memoryStore) QueueJob(job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.jobs {
+		if existing.Queue == job.Queue && existing.Key == job.Key {
+			return nil
+		}
+	}
+	s.jobs = append(s.jobs, job)
+	return nil
+}
+
+func (s *memoryStore) ClaimJob(queue string, lease time.Duration) (Job, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var due *Job
+	for i := range s.jobs {
+		job := &s.jobs[i]
+		if job.Queue != queue || job.DoneAt != nil || job.RunAt.After(now) || job.ClaimedUntil.After(now) {
+			continue
+		}
+		if due == nil || job.RunAt.Before(due.RunAt) {
+			due = job
+		}
+	}
+	if due == nil {
+		return Job{}, false, nil
+	}
+	due.ClaimedUntil = now.Add(lease)
+	due.Attempt++
+	return *due, true, nil
+}
+
+func (s *memoryStore) RetryJob(id primitive.ObjectID, runAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := s.jobIndex(id); i >= 0 {
+		s.jobs[i].RunAt = runAt
+		s.jobs[i].ClaimedUntil = time.Time{}
+	}
+	return nil
+}
+
+func (s *memoryStore) CompleteJob(id primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if i := s.jobIndex(id); i >= 0 {
+		s.jobs[i].DoneAt = &now
+	}
+
+	// drop the jobs done more than jobRetention ago, as the TTL index does in MongoDB
+	jobs := s.jobs[:0]
+	for _, job := range s.jobs {
+		if job.DoneAt == nil || now.Sub(*job.DoneAt) < jobRetention {
+			jobs = append(jobs, job)
+		}
+	}
+	clear(s.jobs[len(jobs):])
+	s.jobs = jobs
+	return nil
+}
+
+// jobIndex returns the position of a job in s.jobs, or -1. The caller must hold s.mu.
+func (s *memoryStore) jobIndex(id primitive.ObjectID) int {
+	for i, job := range s.jobs {
+		if job.ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -6,6 +6,20 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Roles stored on User.Role and signed into the login token
+const (
+	RoleStudent  = "S"
+	RoleLecturer = "L"
+	RoleAdvisor  = "A"
+	RoleHOD      = "H"
+	RoleSenate   = "B"
+	// RoleAdmin is for CSIS staff who administer the system itself, such as its webhooks
+	RoleAdmin = "C"
+)
+
+// Roles lists every role a user can have
+var Roles = []string{RoleStudent, RoleLecturer, RoleAdvisor, RoleHOD, RoleSenate, RoleAdmin}
+
 type User struct {
 	ID        primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
 	UserID    string             `json:"user_id,omitempty" bson:"user_id,omitempty"`
@@ -13,7 +27,7 @@ type User struct {
 	LastName  string             `json:"last_name,omitempty" bson:"last_name,omitempty"`
 	Email     string             `json:"email,omitempty" bson:"email,omitempty"`
 	Role      string             `json:"role,omitempty" bson:"role,omitempty"`
-	Password  string             `json:"password" bson:"password"`
+	Password  string             `json:"password,omitempty" bson:"password"`
 }
 
 type LoginCredentials struct {
@@ -29,6 +43,16 @@ type Student struct {
 	Email     string             `json:"email,omitempty" bson:"email,omitempty"`
 	Courses   []string           `json:"courses,omitempty" bson:"courses,omitempty"`
 	Program   string
+	Level     int `json:"level,omitempty" bson:"level,omitempty"`
+}
+
+// Advisor makes a staff member course advisor for the students of a program, optionally only
+// at some levels
+type Advisor struct {
+	ID      primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+	UserID  string             `json:"user_id" bson:"user_id"`
+	Program string             `json:"program" bson:"program"`
+	Levels  []int              `json:"levels,omitempty" bson:"levels,omitempty"`
 }
 
 type Lecturer struct {
@@ -47,6 +71,16 @@ type Course struct {
 	Semester         string             `json:"semester,omitempty" bson:"semester,omitempty"`
 	StudentsEnrolled []string           `json:"students_enrolled,omitempty" bson:"students_enrolled,omitempty"`
 	Lecturers        []string           `json:"lecturers,omitempty" bson:"lecturers,omitempty"`
+	Coordinator      string             `json:"coordinator,omitempty" bson:"coordinator,omitempty"`
+}
+
+// Department groups courses under the HODs responsible for them
+type Department struct {
+	ID      primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+	Code    string             `json:"code" bson:"code"`
+	Name    string             `json:"name,omitempty" bson:"name,omitempty"`
+	HODs    []string           `json:"hods,omitempty" bson:"hods,omitempty"`
+	Courses []string           `json:"courses,omitempty" bson:"courses,omitempty"`
 }
 
 type Complaint struct {
@@ -55,13 +89,158 @@ type Complaint struct {
 	RequestDetails     string             `json:"request_details,omitempty" bson:"request_details,omitempty"`
 	StudentProof       string             `json:"student_proof,omitempty" bson:"student_proof,omitempty"`
 	LecturerProof      string             `json:"lecturer_proof,omitempty" bson:"lecturer_proof,omitempty"`
+	StudentProofURL    string             `json:"student_proof_url,omitempty" bson:"-"`
+	LecturerProofURL   string             `json:"lecturer_proof_url,omitempty" bson:"-"`
+	ThumbnailURL       string             `json:"thumbnail_url,omitempty" bson:"-"`
 	TestScore          int                `json:"test_score,omitempty" bson:"test_score,omitempty"`
 	CourseConcerned    string             `json:"course_concerned,omitempty" bson:"course_concerned,omitempty"`
 	RespondingLecturer string             `json:"responding_lecturer,omitempty" bson:"responding_lecturer,omitempty"`
-	Status             string             `json:"status,omitempty" bson:"status,omitempty"`
+	Status             Status             `json:"status,omitempty" bson:"status,omitempty"`
 	Reason             string             `json:"reason,omitempty" bson:"reason,omitempty"`
 	CreatedAt          time.Time          `json:"created_at,omitempty" bson:"created_at,omitempty"`
 	UpdatedAt          time.Time          `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+	History            []StatusChange     `json:"history,omitempty" bson:"history,omitempty"`
+	Decline            *Decline           `json:"decline,omitempty" bson:"decline,omitempty"`
+	Assignment         *Assignment        `json:"assignment,omitempty" bson:"assignment,omitempty"`
+	Reassignments      []Reassignment     `json:"reassignments,omitempty" bson:"reassignments,omitempty"`
+	Attachments        []Attachment       `json:"attachments,omitempty" bson:"attachments,omitempty"`
+	DueAt              *time.Time         `json:"due_at,omitempty" bson:"due_at,omitempty"`
+	RemindedAt         *time.Time         `json:"reminded_at,omitempty" bson:"reminded_at,omitempty"`
+	Escalated          bool               `json:"escalated,omitempty" bson:"escalated,omitempty"`
+	Escalations        []Escalation       `json:"escalations,omitempty" bson:"escalations,omitempty"`
+}
+
+// Attachment describes a file uploaded with a complaint or a response to it. Key locates it in
+// the attachment storage; files are stored under names the server generates, so Filename keeps
+// what the uploader called it.
+type Attachment struct {
+	ID       primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+	Key      string             `json:"key" bson:"key"`
+	Filename string             `json:"filename" bson:"filename"`
+	// ContentType is the MIME type detected from the file's content
+	ContentType string `json:"content_type" bson:"content_type"`
+	Size        int64  `json:"size" bson:"size"`
+	// SHA256 is the hex-encoded digest of the file's content
+	SHA256     string    `json:"sha256" bson:"sha256"`
+	UploadedBy Actor     `json:"uploaded_by" bson:"uploaded_by"`
+	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+	// ThumbnailKey is where a small JPEG preview of an image is stored
+	ThumbnailKey string `json:"thumbnail_key,omitempty" bson:"thumbnail_key,omitempty"`
+	// ScannedBy names the malware scanner that passed the file, empty when scanning is off
+	ScannedBy string `json:"scanned_by,omitempty" bson:"scanned_by,omitempty"`
+	// URL is a short-lived signed link to the file, filled in on responses only
+	URL          string `json:"url,omitempty" bson:"-"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty" bson:"-"`
+}
+
+// Comment is a message in the discussion thread of a complaint
+type Comment struct {
+	ID          primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+	ComplaintID primitive.ObjectID `json:"complaint_id" bson:"complaint_id"`
+	Author      Actor              `json:"author" bson:"author"`
+	Body        string             `json:"body" bson:"body"`
+	// VisibleTo lists the roles that can read the comment, always including the author's
+	VisibleTo   []string     `json:"visible_to" bson:"visible_to"`
+	Attachments []Attachment `json:"attachments,omitempty" bson:"attachments,omitempty"`
+	CreatedAt   time.Time    `json:"created_at" bson:"created_at"`
+}
+
+// Notification is an entry in a user's in-app inbox about something that happened to a complaint
+type Notification struct {
+	ID          primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+	UserID      string             `json:"user_id" bson:"user_id"`
+	Kind        string             `json:"kind" bson:"kind"`
+	ComplaintID primitive.ObjectID `json:"complaint_id" bson:"complaint_id"`
+	// EventID is the event the notification is about
+	EventID primitive.ObjectID `json:"event_id" bson:"event_id,omitempty"`
+	Title   string             `json:"title" bson:"title"`
+	// Link is the frontend path where the complaint can be seen
+	Link      string     `json:"link" bson:"link"`
+	Read      bool       `json:"read" bson:"read"`
+	CreatedAt time.Time  `json:"created_at" bson:"created_at"`
+	ReadAt    *time.Time `json:"read_at,omitempty" bson:"read_at,omitempty"`
+}
+
+// Webhook subscribes an outside system to complaint events. Deliveries are signed with Secret.
+type Webhook struct {
+	ID     primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+	URL    string             `json:"url" bson:"url"`
+	Events []EventType        `json:"events" bson:"events"`
+	// Secret is only shown when the webhook is created
+	Secret    string    `json:"secret,omitempty" bson:"secret"`
+	CreatedBy string    `json:"created_by" bson:"created_by"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// WebhookDelivery records one attempt to deliver an event to a webhook
+type WebhookDelivery struct {
+	ID        primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+	WebhookID primitive.ObjectID `json:"webhook_id" bson:"webhook_id"`
+	EventID   primitive.ObjectID `json:"event_id" bson:"event_id"`
+	EventType EventType          `json:"event_type" bson:"event_type"`
+	URL       string             `json:"url" bson:"url"`
+	Attempt   int                `json:"attempt" bson:"attempt"`
+	// StatusCode is the receiver's HTTP status, zero when no response came back
+	StatusCode int    `json:"status_code,omitempty" bson:"status_code,omitempty"`
+	Error      string `json:"error,omitempty" bson:"error,omitempty"`
+	Delivered  bool   `json:"delivered" bson:"delivered"`
+	// NextAttemptAt is when a failed delivery will be tried again, nil when it will not be
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" bson:"next_attempt_at,omitempty"`
+	At            time.Time  `json:"at" bson:"at"`
+}
+
+// Assignment records how the responding lecturer was chosen
+type Assignment struct {
+	Strategy   string    `json:"strategy" bson:"strategy"`
+	Reason     string    `json:"reason" bson:"reason"`
+	AssignedAt time.Time `json:"assigned_at" bson:"assigned_at"`
+}
+
+// Reassignment records a complaint being moved from one lecturer to another
+type Reassignment struct {
+	From   string    `json:"from" bson:"from"`
+	To     string    `json:"to" bson:"to"`
+	Reason string    `json:"reason,omitempty" bson:"reason,omitempty"`
+	Actor  Actor     `json:"actor" bson:"actor"`
+	At     time.Time `json:"at" bson:"at"`
+}
+
+// Escalation records a complaint being sent to the HOD queue from a stage, for missing its
+// deadline there or, as Reason says, because nobody else can review it
+type Escalation struct {
+	Stage Status `json:"stage" bson:"stage"`
+	// DueAt is the deadline that was missed, nil when there was none
+	DueAt  *time.Time `json:"due_at,omitempty" bson:"due_at,omitempty"`
+	Reason string     `json:"reason,omitempty" bson:"reason,omitempty"`
+	At     time.Time  `json:"at" bson:"at"`
+}
+
+// Decline explains why a complaint was declined, by whom and at which stage
+type Decline struct {
+	Reason string    `json:"reason" bson:"reason"`
+	Proof  string    `json:"proof,omitempty" bson:"proof,omitempty"`
+	Stage  Status    `json:"stage" bson:"stage"`
+	Actor  Actor     `json:"actor" bson:"actor"`
+	At     time.Time `json:"at" bson:"at"`
+}
+
+// Actor identifies the authenticated user behind a change
+type Actor struct {
+	UserID string `json:"user_id" bson:"user_id"`
+	Role   string `json:"role" bson:"role"`
+}
+
+// StatusChange is one entry in a complaint's audit trail
+type StatusChange struct {
+	From       Status    `json:"from,omitempty" bson:"from,omitempty"`
+	To         Status    `json:"to" bson:"to"`
+	Actor      Actor     `json:"actor" bson:"actor"`
+	Reason     string    `json:"reason,omitempty" bson:"reason,omitempty"`
+	Attachment string    `json:"attachment,omitempty" bson:"attachment,omitempty"`
+	At         time.Time `json:"at" bson:"at"`
+
+	// files are uploaded with the change and added to the complaint's attachments with it
+	files []Attachment
 }
 
 type Senate struct {
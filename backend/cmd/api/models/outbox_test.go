@@ -0,0 +1,290 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newTestMemoryStore() *memoryStore {
+	s := &memoryStore{deadlines: new(Deadlines), watchWake: make(chan struct{}, 1)}
+	s.events = newEventBus(s)
+	return s
+}
+
+func (s *memoryStore) recordTestEvent() Event {
+	event := Event{ID: primitive.NewObjectID(), Type: EventComplaintCreated, At: time.Now()}
+	s.mu.Lock()
+	s.record(event)
+	s.mu.Unlock()
+	return event
+}
+
+func claimID(t *testing.T, s *memoryStore, lease time.Duration) primitive.ObjectID {
+	t.Helper()
+
+	event, ok, err := s.claimEvent(lease)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		return primitive.NilObjectID
+	}
+	return event.ID
+}
+
+func TestOutboxClaim(t *testing.T) {
+	s := newTestMemoryStore()
+	first, second := s.recordTestEvent(), s.recordTestEvent()
+	const lease = 20 * time.Millisecond
+
+	steps := []struct {
+		name  string
+		do    func()
+		claim primitive.ObjectID
+	}{
+		{"the oldest event is claimed first", nil, first.ID},
+		{"a claimed event is skipped", nil, second.ID},
+		{"nothing is left while both are claimed", nil, primitive.NilObjectID},
+		{"an event whose lease ran out is claimed again", func() { time.Sleep(2 * lease) }, first.ID},
+		{"a dispatched event is not claimed again", func() {
+			if err := s.markDispatched(first.ID); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(2 * lease)
+		}, second.ID},
+		{"the outbox is empty once the rest is dispatched", func() {
+			if err := s.markDispatched(second.ID); err != nil {
+				t.Fatal(err)
+			}
+		}, primitive.NilObjectID},
+	}
+
+	for _, step := range steps {
+		if step.do != nil {
+			step.do()
+		}
+		if got := claimID(t, s, lease); got != step.claim {
+			t.Fatalf("%s: claimed %s, want %s", step.name, got.Hex(), step.claim.Hex())
+		}
+	}
+}
+
+func TestDispatchRetriesUnhandledEvents(t *testing.T) {
+	s := newTestMemoryStore()
+	failing, succeeding := s.recordTestEvent(), s.recordTestEvent()
+
+	handled := map[primitive.ObjectID]int{}
+	fail := true
+	s.events.subscribe(func(event Event) error {
+		handled[event.ID]++
+		return nil
+	})
+	s.events.subscribe(func(event Event) error {
+		if event.ID == failing.ID && fail {
+			return errors.New("mail server down")
+		}
+		return nil
+	})
+
+	s.events.dispatch()
+	if handled[failing.ID] != 1 || handled[succeeding.ID] != 1 {
+		t.Fatalf("first dispatch handled %v, want each event once", handled)
+	}
+	if len(s.outbox) != 1 || s.outbox[0].ID != failing.ID {
+		t.Fatalf("outbox after a failure = %v, want only the failed event", s.outbox)
+	}
+
+	// the failed event waits out its lease before it is tried again
+	s.events.dispatch()
+	if handled[failing.ID] != 1 {
+		t.Fatalf("failed event handled again within its lease")
+	}
+
+	fail = false
+	s.mu.Lock()
+	s.outbox[0].ClaimedUntil = time.Time{}
+	s.mu.Unlock()
+	s.events.dispatch()
+	if handled[failing.ID] != 2 || len(s.outbox) != 0 {
+		t.Fatalf("retry handled the event %d times and left %d in the outbox, want 2 and none", handled[failing.ID], len(s.outbox))
+	}
+}
+
+func TestQueueJob(t *testing.T) {
+	s := newTestMemoryStore()
+
+	jobs := []Job{
+		NewJob("webhook", "a/1", []byte("first")),
+		NewJob("webhook", "a/1", []byte("second")),
+		NewJob("email", "a/1", nil),
+		NewJob("webhook", "a/2", nil),
+	}
+	for _, job := range jobs {
+		if err := s.QueueJob(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(s.jobs) != 3 {
+		t.Fatalf("queued %d jobs, want 3", len(s.jobs))
+	}
+	if string(s.jobs[0].Payload) != "first" {
+		t.Errorf("payload = %q, want the first job's to be kept", s.jobs[0].Payload)
+	}
+}
+
+func TestClaimJob(t *testing.T) {
+	s := newTestMemoryStore()
+	const lease = 20 * time.Millisecond
+
+	older, newer, later := NewJob("webhook", "older", nil), NewJob("webhook", "newer", nil), NewJob("webhook", "later", nil)
+	older.RunAt = time.Now().Add(-time.Minute)
+	later.RunAt = time.Now().Add(time.Hour)
+	for _, job := range []Job{newer, older, later, NewJob("email", "other queue", nil)} {
+		if err := s.QueueJob(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	claim := func() (Job, bool) {
+		t.Helper()
+		job, ok, err := s.ClaimJob("webhook", lease)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return job, ok
+	}
+
+	steps := []struct {
+		name    string
+		do      func()
+		want    primitive.ObjectID
+		attempt int
+	}{
+		{"the job due longest is claimed first", nil, older.ID, 1},
+		{"a claimed job is skipped", nil, newer.ID, 1},
+		{"jobs that are claimed or not yet due are left", nil, primitive.NilObjectID, 0},
+		{"a job whose lease ran out is claimed again", func() {
+			if err := s.CompleteJob(newer.ID); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(2 * lease)
+		}, older.ID, 2},
+		{"a retried job waits until it is due", func() {
+			if err := s.RetryJob(older.ID, time.Now().Add(time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+		}, primitive.NilObjectID, 0},
+		{"a retried job is claimed once it is due", func() {
+			if err := s.RetryJob(older.ID, time.Now()); err != nil {
+				t.Fatal(err)
+			}
+		}, older.ID, 3},
+		{"a completed job is never claimed again", func() {
+			if err := s.CompleteJob(older.ID); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.QueueJob(NewJob("webhook", "older", nil)); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(2 * lease)
+		}, primitive.NilObjectID, 0},
+	}
+
+	for _, step := range steps {
+		if step.do != nil {
+			step.do()
+		}
+		job, ok := claim()
+		if ok != !step.want.IsZero() || job.ID != step.want || job.Attempt != step.attempt {
+			t.Fatalf("%s: claimed %s (attempt %d, %v), want %s (attempt %d)", step.name, job.ID.Hex(), job.Attempt, ok, step.want.Hex(), step.attempt)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		initial time.Duration
+		attempt int
+		want    time.Duration
+	}{
+		{time.Minute, 1, time.Minute},
+		{time.Minute, 2, 2 * time.Minute},
+		{time.Minute, 3, 4 * time.Minute},
+		{time.Minute, 6, 32 * time.Minute},
+		{time.Minute, 12, maxBackoff},
+		{time.Minute, 64, maxBackoff},
+		{time.Minute, 1000, maxBackoff},
+		{30 * time.Second, 100, maxBackoff},
+		{48 * time.Hour, 1, maxBackoff},
+		{time.Minute, 0, time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := Backoff(tt.initial, tt.attempt); got != tt.want {
+			t.Errorf("Backoff(%v, %d) = %v, want %v", tt.initial, tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestCompleteJobDropsOldJobs(t *testing.T) {
+	s := newTestMemoryStore()
+
+	old, done, waiting := NewJob("webhook", "old", nil), NewJob("webhook", "done", nil), NewJob("webhook", "waiting", nil)
+	for _, job := range []Job{old, done, waiting} {
+		if err := s.QueueJob(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	longAgo := time.Now().Add(-jobRetention - time.Minute)
+	s.jobs[0].DoneAt = &longAgo
+
+	if err := s.CompleteJob(done.ID); err != nil {
+		t.Fatal(err)
+	}
+	var kept []string
+	for _, job := range s.jobs {
+		kept = append(kept, job.Key)
+	}
+	if len(kept) != 2 || kept[0] != "done" || kept[1] != "waiting" {
+		t.Fatalf("kept jobs %v, want done and waiting", kept)
+	}
+
+	// the work of a dropped job can be queued again, but not that of one done lately
+	for _, key := range []string{"old", "done"} {
+		if err := s.QueueJob(NewJob("webhook", key, nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(s.jobs) != 3 || s.jobs[2].Key != "old" {
+		t.Errorf("queueing again left %d jobs, want old queued a second time", len(s.jobs))
+	}
+}
+
+func TestJobWorker(t *testing.T) {
+	s := newTestMemoryStore()
+	done := make(chan Job)
+	worker := NewJobWorker(s, "email", func(job Job) {
+		s.CompleteJob(job.ID)
+		done <- job
+	})
+	worker.Start(2)
+
+	job := NewJob("email", "event/kind/to", []byte("payload"))
+	if err := s.QueueJob(job); err != nil {
+		t.Fatal(err)
+	}
+	worker.Wake()
+
+	select {
+	case got := <-done:
+		if got.ID != job.ID || got.Attempt != 1 {
+			t.Errorf("worker ran %+v, want the queued job's first attempt", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker did not run the queued job")
+	}
+}
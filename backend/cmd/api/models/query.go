@@ -0,0 +1,212 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SortFields are the complaint fields a list can be ordered by
+var SortFields = []string{"created_at", "updated_at", "test_score", "course_concerned", "status"}
+
+// ComplaintQuery narrows, orders and pages a list of complaints. The zero value matches
+// everything, oldest first, on a single page.
+type ComplaintQuery struct {
+	Statuses []Status
+	Course   string
+	// Courses and Students, when not nil, restrict the list to complaints about these courses
+	// or from these students; an empty slice matches nothing
+	Courses  []string
+	Students []string
+	// From and To bound the creation date, both inclusive; a zero time leaves that side open
+	From       time.Time
+	To         time.Time
+	Sort       string
+	Descending bool
+	// Page starts at 1; PerPage of 0 returns every match
+	Page    int
+	PerPage int
+}
+
+// ComplaintPage is one page of a complaint list together with the size of the whole list
+type ComplaintPage struct {
+	Complaints []Complaint `json:"complaints"`
+	Total      int64       `json:"total"`
+	Page       int         `json:"page"`
+	PerPage    int         `json:"per_page"`
+	TotalPages int         `json:"total_pages"`
+}
+
+// Validate checks that the query only sorts by a known field
+func (q ComplaintQuery) Validate() error {
+	if q.Sort == "" {
+		return nil
+	}
+	for _, field := range SortFields {
+		if q.Sort == field {
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot sort by %q, expected one of %s", q.Sort, strings.Join(SortFields, ", "))
+}
+
+func (q ComplaintQuery) sortField() string {
+	if q.Sort == "" {
+		return "created_at"
+	}
+	return q.Sort
+}
+
+func (q ComplaintQuery) page() int {
+	if q.Page < 1 {
+		return 1
+	}
+	return q.Page
+}
+
+// newPage wraps the complaints on the requested page
+func (q ComplaintQuery) newPage(complaints []Complaint, total int64) ComplaintPage {
+	if complaints == nil {
+		complaints = []Complaint{}
+	}
+
+	page := ComplaintPage{
+		Complaints: complaints,
+		Total:      total,
+		Page:       q.page(),
+		PerPage:    q.PerPage,
+		TotalPages: 1,
+	}
+	if q.PerPage > 0 {
+		page.TotalPages = int((total + int64(q.PerPage) - 1) / int64(q.PerPage))
+	}
+	return page
+}
+
+// filter returns base narrowed by the query's filters
+func (q ComplaintQuery) filter(base bson.M) bson.M {
+	conditions := bson.A{base}
+	if len(q.Statuses) > 0 {
+		conditions = append(conditions, bson.M{"status": bson.M{"$in": q.Statuses}})
+	}
+	if q.Course != "" {
+		conditions = append(conditions, bson.M{"course_concerned": q.Course})
+	}
+	if q.Courses != nil {
+		conditions = append(conditions, bson.M{"course_concerned": bson.M{"$in": q.Courses}})
+	}
+	if q.Students != nil {
+		conditions = append(conditions, bson.M{"requesting_student": bson.M{"$in": q.Students}})
+	}
+	created := bson.M{}
+	if !q.From.IsZero() {
+		created["$gte"] = q.From
+	}
+	if !q.To.IsZero() {
+		created["$lte"] = q.To
+	}
+	if len(created) > 0 {
+		conditions = append(conditions, bson.M{"created_at": created})
+	}
+
+	if len(conditions) == 1 {
+		return base
+	}
+	return bson.M{"$and": conditions}
+}
+
+// findOptions returns the sort, skip and limit for the query
+func (q ComplaintQuery) findOptions() *options.FindOptions {
+	order := 1
+	if q.Descending {
+		order = -1
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: q.sortField(), Value: order}, {Key: "_id", Value: order}})
+	if q.PerPage > 0 {
+		opts.SetSkip(int64((q.page() - 1) * q.PerPage)).SetLimit(int64(q.PerPage))
+	}
+	return opts
+}
+
+// matches is the in-memory counterpart of filter
+func (q ComplaintQuery) matches(c Complaint) bool {
+	if len(q.Statuses) > 0 {
+		found := false
+		for _, status := range q.Statuses {
+			if c.Status == status {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.Course != "" && c.CourseConcerned != q.Course {
+		return false
+	}
+	if q.Courses != nil && !contains(q.Courses, c.CourseConcerned) {
+		return false
+	}
+	if q.Students != nil && !contains(q.Students, c.RequestingStudent) {
+		return false
+	}
+	if !q.From.IsZero() && c.CreatedAt.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && c.CreatedAt.After(q.To) {
+		return false
+	}
+	return true
+}
+
+// apply is the in-memory counterpart of findOptions: it sorts complaints and cuts out the requested page
+func (q ComplaintQuery) apply(complaints []Complaint) ComplaintPage {
+	less := func(a, b Complaint) bool {
+		switch q.sortField() {
+		case "updated_at":
+			return a.UpdatedAt.Before(b.UpdatedAt)
+		case "test_score":
+			return a.TestScore < b.TestScore
+		case "course_concerned":
+			return a.CourseConcerned < b.CourseConcerned
+		case "status":
+			return a.Status < b.Status
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	sort.SliceStable(complaints, func(i, j int) bool {
+		if q.Descending {
+			return less(complaints[j], complaints[i])
+		}
+		return less(complaints[i], complaints[j])
+	})
+
+	total := int64(len(complaints))
+	if q.PerPage > 0 {
+		start := (q.page() - 1) * q.PerPage
+		if start > len(complaints) {
+			start = len(complaints)
+		}
+		end := start + q.PerPage
+		if end > len(complaints) {
+			end = len(complaints)
+		}
+		complaints = complaints[start:end]
+	}
+	return q.newPage(complaints, total)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
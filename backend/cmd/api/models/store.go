@@ -0,0 +1,215 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrUserNotFound       = errors.New("User not found")
+	ErrCourseNotFound     = errors.New("Course not found")
+	ErrStudentNotFound    = errors.New("Student not found")
+	ErrLecturerNotFound   = errors.New("Lecturer not found")
+	ErrDepartmentNotFound = errors.New("Department not found")
+	ErrAttachmentNotFound = errors.New("Attachment not found")
+	ErrWebhookNotFound    = errors.New("Webhook not found")
+)
+
+// Store groups the repositories the controllers read and write through, so the API can run
+// against MongoDB or entirely in memory
+type Store struct {
+	Users         UserStore
+	Complaints    ComplaintStore
+	Courses       CourseStore
+	Students      StudentStore
+	Lecturers     LecturerStore
+	Departments   DepartmentStore
+	Advisors      AdvisorStore
+	Notifications NotificationStore
+	Webhooks      WebhookStore
+	Comments      CommentStore
+	Jobs          JobStore
+
+	events    *eventBus
+	deadlines *Deadlines
+}
+
+type UserStore interface {
+	Register(user User) (string, error)
+	GetUserByObjectID(id primitive.ObjectID) (User, error)
+	GetUserByUserID(userID string) (User, error)
+	GetUsersByRole(role string) ([]User, error)
+}
+
+type ComplaintStore interface {
+	CreateNewComplaint(complaint Complaint) (string, error)
+	GetComplaintByObjectId(id primitive.ObjectID) (Complaint, error)
+	GetComplaintsByStaffId(id string, query ComplaintQuery) (ComplaintPage, error)
+	CountOpenComplaintsByStaffId(id string) (int64, error)
+	GetComplaintsByStudentId(id string, query ComplaintQuery) (ComplaintPage, error)
+	GetComplaintsByStatus(query ComplaintQuery, statuses ...Status) (ComplaintPage, error)
+	// GetComplaintsInHODQueue returns the complaints approved by an advisor together with the open
+	// complaints escalated for missing a deadline
+	GetComplaintsInHODQueue(query ComplaintQuery) (ComplaintPage, error)
+	// GetComplaintsDueBy returns the open, unescalated complaints due at or before t
+	GetComplaintsDueBy(t time.Time) ([]Complaint, error)
+	GetComplaintsByCourseCode(id, courseCode string, query ComplaintQuery) (ComplaintPage, error)
+	GetComplaintByCourseCode(id, courseCode string) (*Complaint, error)
+	ComplaintAlreadyExists(id, courseCode string) (bool, error)
+	ChangeComplaintStatus(id string, newStatus Status, actor Actor) error
+	ChangeComplaintStatusLecturer(id string, newStatus Status, reason string, proofs []Attachment, actor Actor) error
+	DeclineComplaint(id string, reason string, proofs []Attachment, actor Actor) error
+	ChangeStatusToByHOD(id string, actor Actor) error
+	ReassignComplaint(id string, reassignment Reassignment, assignment Assignment) error
+	AddAttachments(id string, attachments []Attachment) error
+	RemoveAttachment(id string, attachmentID primitive.ObjectID) error
+	// RemindComplaint notes that the reviewers of a complaint at stage were reminded of its
+	// deadline, unless it has moved on or they already were
+	RemindComplaint(id string, stage Status) error
+	// EscalateComplaint sends a complaint that missed its deadline at escalation.Stage to the HOD
+	// queue, unless it has moved on or already is there
+	EscalateComplaint(id string, escalation Escalation) error
+}
+
+type CourseStore interface {
+	GetCourseByCourseCode(courseCode string) (Course, error)
+}
+
+type StudentStore interface {
+	GetStudentById(userID string) (Student, error)
+	GetMatricNo(email string) (string, error)
+	GetStudentsByProgram(program string, levels []int) ([]Student, error)
+}
+
+type LecturerStore interface {
+	GetStaffById(userID string) (Lecturer, error)
+}
+
+type AdvisorStore interface {
+	GetAdvisorsByUserID(userID string) ([]Advisor, error)
+	GetAdvisorsForStudent(student Student) ([]Advisor, error)
+}
+
+// NotificationStore keeps the in-app inboxes of users
+type NotificationStore interface {
+	// CreateNotification adds a notification to a user's inbox. One with the same event, user
+	// and kind as an earlier one is not added again, so an event can be handled twice.
+	CreateNotification(notification Notification) error
+	// GetNotifications returns a user's newest notifications first, at most limit of them
+	GetNotifications(userID string, unreadOnly bool, limit int) ([]Notification, error)
+	CountUnreadNotifications(userID string) (int64, error)
+	// MarkNotificationsRead marks the given notifications of a user read, or all of them when
+	// ids is empty, and returns how many changed
+	MarkNotificationsRead(userID string, ids []primitive.ObjectID) (int64, error)
+}
+
+// CommentStore keeps the discussion threads of complaints
+type CommentStore interface {
+	// AddComment adds a comment to the thread of its complaint
+	AddComment(comment Comment) error
+	// GetComments returns the comments on a complaint that role can read, oldest first
+	GetComments(complaintID primitive.ObjectID, role string) ([]Comment, error)
+}
+
+// WebhookStore keeps webhook subscriptions and the log of their deliveries
+type WebhookStore interface {
+	CreateWebhook(webhook Webhook) (string, error)
+	GetWebhooks() ([]Webhook, error)
+	GetWebhook(id primitive.ObjectID) (Webhook, error)
+	DeleteWebhook(id primitive.ObjectID) error
+	RecordDelivery(delivery WebhookDelivery) error
+	// GetDeliveries returns the newest delivery attempts of a webhook first, at most limit of them
+	GetDeliveries(webhookID primitive.ObjectID, limit int) ([]WebhookDelivery, error)
+}
+
+type DepartmentStore interface {
+	GetDepartmentsByHOD(userID string) ([]Department, error)
+	GetDepartmentByCourseCode(courseCode string) (Department, error)
+}
+
+// prepareTransition checks a status change against the workflow for a complaint as it
+// currently stands and fills in where it moves from and when
+func prepareTransition(complaint Complaint, change StatusChange) (StatusChange, error) {
+	if !complaint.CanMoveTo(change.To) {
+		return change, transitionError(complaint.Status, change.To)
+	}
+
+	change.From = complaint.Status
+	change.At = time.Now()
+	return change, nil
+}
+
+// declineFor returns the decline details recorded when change declines a complaint, or nil
+func declineFor(change StatusChange) *Decline {
+	if change.To != StatusDeclined {
+		return nil
+	}
+	return &Decline{
+		Reason: change.Reason,
+		Proof:  change.Attachment,
+		Stage:  change.From,
+		Actor:  change.Actor,
+		At:     change.At,
+	}
+}
+
+// lecturerChange is the change a lecturer's response makes; the first proof is the one shown
+// as the lecturer's proof
+func lecturerChange(newStatus Status, reason string, proofs []Attachment, actor Actor) StatusChange {
+	return StatusChange{
+		To:         newStatus,
+		Actor:      actor,
+		Reason:     reason,
+		Attachment: firstKey(proofs),
+		files:      proofs,
+	}
+}
+
+func declineChange(reason string, proofs []Attachment, actor Actor) (StatusChange, error) {
+	if reason == "" {
+		return StatusChange{}, errors.New("a reason is required to decline a complaint")
+	}
+
+	return StatusChange{
+		To:         StatusDeclined,
+		Actor:      actor,
+		Reason:     reason,
+		Attachment: firstKey(proofs),
+		files:      proofs,
+	}, nil
+}
+
+func firstKey(attachments []Attachment) string {
+	if len(attachments) == 0 {
+		return ""
+	}
+	return attachments[0].Key
+}
+
+// closedConflict is returned when the attachments of a closed complaint are changed
+func closedConflict(complaint Complaint) error {
+	return fmt.Errorf("%w: complaint is %q and its attachments can no longer be changed", ErrInvalidTransition, complaint.Status)
+}
+
+// reassignConflict is returned when a complaint to reassign is closed or no longer with the expected lecturer
+func reassignConflict(complaint Complaint, reassignment Reassignment) error {
+	if complaint.Status.IsFinal() {
+		return fmt.Errorf("%w: complaint is %q and can no longer be reassigned", ErrInvalidTransition, complaint.Status)
+	}
+	if complaint.RespondingLecturer != reassignment.From {
+		return fmt.Errorf("%w: complaint is assigned to %s, not %s", ErrInvalidTransition, complaint.RespondingLecturer, reassignment.From)
+	}
+	return fmt.Errorf("%w: complaint was changed by someone else, reload and try again", ErrInvalidTransition)
+}
+
+// deadlineConflict is returned when a complaint to remind or escalate has left the stage or
+// was already reminded or escalated
+func deadlineConflict(complaint Complaint, stage Status) error {
+	if complaint.Status != stage {
+		return fmt.Errorf("%w: complaint is %q, no longer %q", ErrInvalidTransition, complaint.Status, stage)
+	}
+	return fmt.Errorf("%w: complaint was already reminded or escalated", ErrInvalidTransition)
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Status is the stage a complaint has reached in the approval workflow
+type Status string
+
+const (
+	StatusPending            Status = "Pending"
+	StatusApprovedByLecturer Status = "Approved By Lecturer"
+	StatusApprovedByAdvisor  Status = "Approved By Course Advisor"
+	StatusApprovedByHOD      Status = "Approved By HOD"
+	StatusApprovedBySenate   Status = "Approved By Senate"
+	StatusDeclined           Status = "Declined"
+)
+
+// ErrComplaintNotFound is returned when no complaint matches the given ID
+var ErrComplaintNotFound = errors.New("Complaint not found")
+
+// ErrInvalidTransition is returned when a status change is not allowed by the workflow
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// transitions lists, for every non-final status, the statuses a complaint may move to next
+var transitions = map[Status][]Status{
+	StatusPending:            {StatusApprovedByLecturer, StatusDeclined},
+	StatusApprovedByLecturer: {StatusApprovedByAdvisor, StatusDeclined},
+	StatusApprovedByAdvisor:  {StatusApprovedByHOD, StatusDeclined},
+	StatusApprovedByHOD:      {StatusApprovedBySenate, StatusDeclined},
+}
+
+// escalatedTransitions lists the further moves open to a complaint escalated to the HOD queue
+// for missing its deadline: the HOD may approve it in place of the lecturer or course advisor
+var escalatedTransitions = map[Status][]Status{
+	StatusPending:            {StatusApprovedByHOD},
+	StatusApprovedByLecturer: {StatusApprovedByHOD},
+}
+
+// reviewerRoles gives, for every non-final status, the role that approves or declines a
+// complaint in it
+var reviewerRoles = map[Status]string{
+	StatusPending:            RoleLecturer,
+	StatusApprovedByLecturer: RoleAdvisor,
+	StatusApprovedByAdvisor:  RoleHOD,
+	StatusApprovedByHOD:      RoleSenate,
+}
+
+// Statuses lists every status in workflow order
+var Statuses = []Status{
+	StatusPending,
+	StatusApprovedByLecturer,
+	StatusApprovedByAdvisor,
+	StatusApprovedByHOD,
+	StatusApprovedBySenate,
+	StatusDeclined,
+}
+
+// OpenStatuses returns the statuses a complaint can still move on from
+func OpenStatuses() []Status {
+	var open []Status
+	for _, status := range Statuses {
+		if !status.IsFinal() {
+			open = append(open, status)
+		}
+	}
+	return open
+}
+
+// IsFinal reports whether no further transitions are possible from s
+func (s Status) IsFinal() bool {
+	return len(transitions[s]) == 0
+}
+
+// CanTransition reports whether the workflow allows moving from one status to another
+func CanTransition(from, to Status) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CanMoveTo reports whether the workflow allows moving c to status, taking its escalation into
+// account
+func (c Complaint) CanMoveTo(status Status) bool {
+	if CanTransition(c.Status, status) {
+		return true
+	}
+	if !c.Escalated {
+		return false
+	}
+	for _, next := range escalatedTransitions[c.Status] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// AwaitsRole reports whether c waits on role to approve or decline it: the reviewers of its
+// current stage, and the HOD too once it was escalated to them
+func (c Complaint) AwaitsRole(role string) bool {
+	if c.Status.IsFinal() {
+		return false
+	}
+	if reviewerRoles[c.Status] == role {
+		return true
+	}
+	return c.Escalated && role == RoleHOD && len(escalatedTransitions[c.Status]) > 0
+}
+
+func transitionError(from, to Status) error {
+	return fmt.Errorf("%w: cannot move complaint from %q to %q", ErrInvalidTransition, from, to)
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to Status
+		want     bool
+	}{
+		{StatusPending, StatusApprovedByLecturer, true},
+		{StatusPending, StatusDeclined, true},
+		{StatusPending, StatusApprovedByAdvisor, false},
+		{StatusPending, StatusApprovedByHOD, false},
+		{StatusApprovedByLecturer, StatusApprovedByAdvisor, true},
+		{StatusApprovedByLecturer, StatusApprovedByHOD, false},
+		{StatusApprovedByLecturer, StatusDeclined, true},
+		{StatusApprovedByAdvisor, StatusApprovedByHOD, true},
+		{StatusApprovedByAdvisor, StatusApprovedBySenate, false},
+		{StatusApprovedByHOD, StatusApprovedBySenate, true},
+		{StatusApprovedByHOD, StatusDeclined, true},
+		{StatusApprovedBySenate, StatusDeclined, false},
+		{StatusDeclined, StatusPending, false},
+		{StatusDeclined, StatusApprovedByLecturer, false},
+		{StatusPending, StatusPending, false},
+	}
+
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestIsFinal(t *testing.T) {
+	for _, status := range Statuses {
+		want := status == StatusApprovedBySenate || status == StatusDeclined
+		if got := status.IsFinal(); got != want {
+			t.Errorf("%q.IsFinal() = %v, want %v", status, got, want)
+		}
+	}
+
+	open := OpenStatuses()
+	if len(open) != 4 || open[0] != StatusPending || open[3] != StatusApprovedByHOD {
+		t.Errorf("OpenStatuses() = %v, want the four review stages in order", open)
+	}
+}
+
+func TestCanMoveTo(t *testing.T) {
+	tests := []struct {
+		status    Status
+		escalated bool
+		to        Status
+		want      bool
+	}{
+		{StatusPending, false, StatusApprovedByHOD, false},
+		{StatusPending, true, StatusApprovedByHOD, true},
+		{StatusPending, true, StatusApprovedByLecturer, true},
+		{StatusApprovedByLecturer, false, StatusApprovedByHOD, false},
+		{StatusApprovedByLecturer, true, StatusApprovedByHOD, true},
+		{StatusApprovedByLecturer, true, StatusApprovedBySenate, false},
+		{StatusApprovedByAdvisor, true, StatusApprovedBySenate, false},
+		{StatusDeclined, true, StatusApprovedByHOD, false},
+	}
+
+	for _, tt := range tests {
+		complaint := Complaint{Status: tt.status, Escalated: tt.escalated}
+		if got := complaint.CanMoveTo(tt.to); got != tt.want {
+			t.Errorf("%q (escalated %v).CanMoveTo(%q) = %v, want %v", tt.status, tt.escalated, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestAwaitsRole(t *testing.T) {
+	tests := []struct {
+		status    Status
+		escalated bool
+		role      string
+		want      bool
+	}{
+		{StatusPending, false, RoleLecturer, true},
+		{StatusPending, false, RoleHOD, false},
+		{StatusPending, false, RoleSenate, false},
+		{StatusPending, true, RoleHOD, true},
+		{StatusApprovedByLecturer, false, RoleAdvisor, true},
+		{StatusApprovedByLecturer, false, RoleLecturer, false},
+		{StatusApprovedByLecturer, true, RoleHOD, true},
+		{StatusApprovedByAdvisor, false, RoleHOD, true},
+		{StatusApprovedByAdvisor, false, RoleAdvisor, false},
+		{StatusApprovedByHOD, false, RoleSenate, true},
+		{StatusApprovedByHOD, false, RoleLecturer, false},
+		{StatusApprovedByHOD, true, RoleHOD, false},
+		{StatusDeclined, false, RoleSenate, false},
+		{StatusApprovedBySenate, false, RoleSenate, false},
+		{StatusPending, false, RoleStudent, false},
+	}
+
+	for _, tt := range tests {
+		complaint := Complaint{Status: tt.status, Escalated: tt.escalated}
+		if got := complaint.AwaitsRole(tt.role); got != tt.want {
+			t.Errorf("%q (escalated %v).AwaitsRole(%q) = %v, want %v", tt.status, tt.escalated, tt.role, got, tt.want)
+		}
+	}
+}
+
+func TestPrepareTransition(t *testing.T) {
+	complaint := Complaint{Status: StatusApprovedByLecturer}
+
+	change, err := prepareTransition(complaint, StatusChange{To: StatusApprovedByAdvisor})
+	if err != nil {
+		t.Fatalf("prepareTransition() error = %v", err)
+	}
+	if change.From != StatusApprovedByLecturer || change.At.IsZero() {
+		t.Errorf("prepareTransition() = %+v, want From set and At filled in", change)
+	}
+
+	_, err = prepareTransition(complaint, StatusChange{To: StatusApprovedBySenate})
+	if !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("prepareTransition() to Senate error = %v, want ErrInvalidTransition", err)
+	}
+}
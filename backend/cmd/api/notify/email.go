@@ -0,0 +1,177 @@
+package notify
+
+import (
+	"bytes"
+	"complaints/cmd/api/models"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed templates/*.txt
+var templateFiles embed.FS
+
+// emailTemplates holds a template per Kind, named after it. Each renders a "Subject:" line, a
+// blank line and the body.
+var emailTemplates = template.Must(template.ParseFS(templateFiles, "templates/*.txt"))
+
+// Message is an email to a single recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender sends email
+type Sender interface {
+	Send(message Message) error
+}
+
+// queue is the job queue emails wait in
+const queue = "email"
+
+const (
+	// mailWorkers is how many emails are sent at once
+	mailWorkers = 2
+	// mailAttempts is how many times an email is tried before it is given up on
+	mailAttempts = 5
+	// mailBackoff is the wait after a failed email, doubling with each retry
+	mailBackoff = time.Minute
+)
+
+// Mailer is a Channel that emails notifications. Each email is a job in the store, sent in the
+// background, so a slow mail server does not hold up the store's other subscribers and a queued
+// email survives a restart.
+type Mailer struct {
+	sender Sender
+	jobs   models.JobStore
+	worker *models.JobWorker
+	appURL string
+}
+
+// NewMailer returns a Mailer queueing emails in jobs and sending them through sender, with links
+// to the frontend at appURL
+func NewMailer(sender Sender, jobs models.JobStore, appURL string) *Mailer {
+	m := &Mailer{
+		sender: sender,
+		jobs:   jobs,
+		appURL: strings.TrimSuffix(appURL, "/"),
+	}
+	m.worker = models.NewJobWorker(jobs, queue, m.send)
+	m.worker.Start(mailWorkers)
+	return m
+}
+
+// Deliver renders and queues the email for a notification. Recipients without an email address
+// are skipped, and so are notifications that cannot be rendered.
+func (m *Mailer) Deliver(notification Notification) error {
+	if notification.Recipient.Email == "" {
+		log.Printf("No email address for %s, skipping %s notification", notification.Recipient.UserID, notification.Kind)
+		return nil
+	}
+
+	message, err := m.render(notification)
+	if err != nil {
+		log.Println("Unable to render notification email:", err)
+		return nil
+	}
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("unable to encode notification email: %w", err)
+	}
+
+	// one email per event, kind and address, however often the event is handled
+	key := strings.Join([]string{notification.Event.ID.Hex(), string(notification.Kind), message.To}, "/")
+	if err := m.jobs.QueueJob(models.NewJob(queue, key, payload)); err != nil {
+		return fmt.Errorf("unable to queue notification email: %w", err)
+	}
+	m.worker.Wake()
+	return nil
+}
+
+// emailData is what the templates are executed with
+type emailData struct {
+	Name      string
+	Complaint models.Complaint
+	Change    *models.StatusChange
+	// Escalation is set for KindEscalated
+	Escalation *models.Escalation
+	// Comment is set for KindComment
+	Comment *models.Comment
+	Link    string
+}
+
+func (m *Mailer) render(notification Notification) (Message, error) {
+	subject, body, err := render(notification, m.appURL)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{To: notification.Recipient.Email, Subject: subject, Body: body}, nil
+}
+
+// render executes the template of a notification's kind, with links to the frontend at appURL
+func render(notification Notification, appURL string) (subject, body string, err error) {
+	data := emailData{
+		Name:       notification.Recipient.Name,
+		Complaint:  notification.Event.Complaint,
+		Change:     notification.Event.Change,
+		Escalation: notification.Event.Escalation,
+		Comment:    notification.Event.Comment,
+		Link:       appURL + Link(notification.Recipient.Role, notification.Event.Complaint.ID.Hex()),
+	}
+	if data.Name == "" {
+		data.Name = notification.Recipient.UserID
+	}
+
+	var out bytes.Buffer
+	if err := emailTemplates.ExecuteTemplate(&out, string(notification.Kind)+".txt", data); err != nil {
+		return "", "", err
+	}
+	head, body, ok := strings.Cut(out.String(), "\n\n")
+	subject, found := strings.CutPrefix(head, "Subject: ")
+	if !ok || !found {
+		return "", "", fmt.Errorf("template %s does not start with a Subject line", notification.Kind)
+	}
+	return subject, body, nil
+}
+
+// send makes an attempt at an email job, retrying it later if the mail server fails
+func (m *Mailer) send(job models.Job) {
+	var message Message
+	err := json.Unmarshal(job.Payload, &message)
+	if err == nil {
+		err = m.sender.Send(message)
+	}
+
+	if err != nil && job.Attempt < mailAttempts {
+		log.Printf("Unable to email %s, will retry: %v", message.To, err)
+		err = m.jobs.RetryJob(job.ID, time.Now().Add(models.Backoff(mailBackoff, job.Attempt)))
+	} else {
+		if err != nil {
+			log.Printf("Unable to email %s, giving up: %v", message.To, err)
+		}
+		err = m.jobs.CompleteJob(job.ID)
+	}
+	if err != nil {
+		log.Println("Unable to update email job:", err)
+	}
+}
+
+// Link returns the frontend path where someone with role deals with a complaint
+func Link(role, complaintID string) string {
+	switch role {
+	case models.RoleLecturer:
+		return "/lecturer-dashboard/complaint/" + complaintID
+	case models.RoleAdvisor:
+		return "/advisor-dashboard/complaint/" + complaintID
+	case models.RoleHOD:
+		return "/hod-dashboard/complaint/" + complaintID
+	case models.RoleSenate:
+		return "/senate-dashboard/complaint/" + complaintID
+	}
+	return "/student-dashboard"
+}
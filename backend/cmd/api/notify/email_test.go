@@ -0,0 +1,137 @@
+package notify
+
+import (
+	"complaints/cmd/api/models"
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// kinds lists every Kind, each of which needs a template
+var kinds = []Kind{KindReceived, KindAssigned, KindProgress, KindReview, KindDeclined, KindReminder, KindEscalated, KindComment}
+
+// testEvent returns an event about a complaint with everything a template may show filled in
+func testEvent(status models.Status) models.Event {
+	due := time.Date(2024, 3, 6, 16, 0, 0, 0, time.UTC)
+	actor := models.Actor{UserID: "lec1", Role: models.RoleLecturer}
+	complaint := models.Complaint{
+		ID:                 primitive.NewObjectID(),
+		RequestingStudent:  "stu1",
+		RespondingLecturer: "lec1",
+		CourseConcerned:    "CSC101",
+		RequestDetails:     "Question 4 was not marked",
+		Status:             status,
+		DueAt:              &due,
+	}
+	if status == models.StatusDeclined {
+		complaint.Decline = &models.Decline{Reason: "the script was marked correctly", Stage: models.StatusPending, Actor: actor}
+	}
+	return models.Event{
+		ID:         primitive.NewObjectID(),
+		Complaint:  complaint,
+		Change:     &models.StatusChange{From: models.StatusPending, To: status, Actor: actor},
+		Escalation: &models.Escalation{Stage: models.StatusPending, DueAt: &due},
+		Comment:    &models.Comment{Author: actor, Body: "Can you send the marking scheme?", VisibleTo: []string{models.RoleStudent, models.RoleLecturer}},
+		At:         due,
+	}
+}
+
+func TestEveryKindHasATemplate(t *testing.T) {
+	names, err := fs.Glob(templateFiles, "templates/*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != len(kinds) {
+		t.Errorf("found %d templates for %d kinds: %v", len(names), len(kinds), names)
+	}
+	for _, kind := range kinds {
+		if emailTemplates.Lookup(string(kind)+".txt") == nil {
+			t.Errorf("no template for %q", kind)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	overdue := testEvent(models.StatusPending)
+	noAdvisor := testEvent(models.StatusApprovedByLecturer)
+	noAdvisor.Escalation = &models.Escalation{Stage: models.StatusApprovedByLecturer, Reason: "the student has no course advisor"}
+
+	tests := []struct {
+		kind    Kind
+		role    string
+		event   models.Event
+		subject string
+		body    []string
+	}{
+		{KindReceived, models.RoleStudent, testEvent(models.StatusPending), "Your complaint about CSC101 was received",
+			[]string{"Hello Ada Obi,", "/student-dashboard"}},
+		{KindAssigned, models.RoleLecturer, testEvent(models.StatusPending), "A complaint about CSC101 was assigned to you",
+			[]string{"stu1 has a complaint", "Question 4 was not marked", "/lecturer-dashboard/complaint/"}},
+		{KindProgress, models.RoleStudent, testEvent(models.StatusApprovedByAdvisor), "Your complaint about CSC101 is now Approved By Course Advisor",
+			[]string{"moved on to the next stage"}},
+		{KindProgress, models.RoleStudent, testEvent(models.StatusApprovedBySenate), "Your complaint about CSC101 is now Approved By Senate",
+			[]string{"the process is complete"}},
+		{KindReview, models.RoleHOD, testEvent(models.StatusApprovedByAdvisor), "A complaint about CSC101 awaits your review",
+			[]string{`is now "Approved By Course Advisor"`, "/hod-dashboard/complaint/"}},
+		{KindDeclined, models.RoleStudent, testEvent(models.StatusDeclined), "Your complaint about CSC101 was declined",
+			[]string{`declined at the "Pending" stage.`, "Reason: the script was marked correctly"}},
+		{KindReminder, models.RoleLecturer, testEvent(models.StatusPending), "A complaint about CSC101 is due Wed 6 Mar",
+			[]string{"due by Wed 6 Mar 2024 16:00 UTC", "will be escalated to the HOD"}},
+		{KindReminder, models.RoleSenate, testEvent(models.StatusApprovedByHOD), "A complaint about CSC101 is due Wed 6 Mar",
+			[]string{"/senate-dashboard/complaint/"}},
+		{KindEscalated, models.RoleHOD, overdue, "A complaint about CSC101 was escalated to the HOD",
+			[]string{"was due by Wed 6 Mar 2024 16:00 UTC while \"Pending\""}},
+		{KindEscalated, models.RoleHOD, noAdvisor, "A complaint about CSC101 was escalated to the HOD",
+			[]string{"but the student has no course advisor"}},
+		{KindComment, models.RoleStudent, testEvent(models.StatusPending), "New comment on the complaint about CSC101",
+			[]string{"lec1 commented", "Can you send the marking scheme?"}},
+	}
+
+	covered := map[Kind]bool{}
+	for _, tt := range tests {
+		covered[tt.kind] = true
+		notification := Notification{
+			Kind:      tt.kind,
+			Recipient: Recipient{UserID: "someone", Role: tt.role, Name: "Ada Obi"},
+			Event:     tt.event,
+		}
+
+		subject, body, err := render(notification, "https://app.example")
+		if err != nil {
+			t.Errorf("%s: render() error = %v", tt.kind, err)
+			continue
+		}
+		if subject != tt.subject {
+			t.Errorf("%s: subject = %q, want %q", tt.kind, subject, tt.subject)
+		}
+		link := "https://app.example" + Link(tt.role, tt.event.Complaint.ID.Hex())
+		for _, want := range append(tt.body, link) {
+			if !strings.Contains(body, want) {
+				t.Errorf("%s: body does not contain %q:\n%s", tt.kind, want, body)
+			}
+		}
+		if strings.Contains(body, "<no value>") || strings.Contains(subject, "\n") {
+			t.Errorf("%s: rendered with missing data:\n%s\n\n%s", tt.kind, subject, body)
+		}
+	}
+	for _, kind := range kinds {
+		if !covered[kind] {
+			t.Errorf("no render test for %q", kind)
+		}
+	}
+}
+
+func TestRenderFallsBackToUserID(t *testing.T) {
+	notification := Notification{Kind: KindReceived, Recipient: Recipient{UserID: "stu1", Role: models.RoleStudent}, Event: testEvent(models.StatusPending)}
+
+	_, body, err := render(notification, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(body, "Hello stu1,") {
+		t.Errorf("body = %q, want it to greet the user ID", body)
+	}
+}
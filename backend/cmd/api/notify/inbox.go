@@ -0,0 +1,43 @@
+package notify
+
+import (
+	"complaints/cmd/api/models"
+	"fmt"
+	"log"
+)
+
+// Inbox is a Channel that keeps notifications in the store for users to read in the app. The
+// title of each is the subject its email would have.
+type Inbox struct {
+	notifications models.NotificationStore
+}
+
+// NewInbox returns an Inbox saving to notifications
+func NewInbox(notifications models.NotificationStore) *Inbox {
+	return &Inbox{notifications: notifications}
+}
+
+// Deliver saves a notification to its recipient's inbox. One that cannot be rendered is logged
+// and dropped, as trying again would not help.
+func (i *Inbox) Deliver(notification Notification) error {
+	title, _, err := render(notification, "")
+	if err != nil {
+		log.Println("Unable to render notification:", err)
+		return nil
+	}
+
+	complaint := notification.Event.Complaint
+	err = i.notifications.CreateNotification(models.Notification{
+		UserID:      notification.Recipient.UserID,
+		Kind:        string(notification.Kind),
+		ComplaintID: complaint.ID,
+		EventID:     notification.Event.ID,
+		Title:       title,
+		Link:        Link(notification.Recipient.Role, complaint.ID.Hex()),
+		CreatedAt:   notification.Event.At,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to save notification: %w", err)
+	}
+	return nil
+}
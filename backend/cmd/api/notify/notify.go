@@ -0,0 +1,265 @@
+// Package notify tells the people involved in a complaint when something happens to it.
+package notify
+
+import (
+	"complaints/cmd/api/models"
+	"errors"
+	"log"
+	"strings"
+)
+
+// Kind is what a notification tells its recipient
+type Kind string
+
+const (
+	// KindReceived tells a student their complaint was filed
+	KindReceived Kind = "received"
+	// KindAssigned tells a lecturer a complaint was assigned to them
+	KindAssigned Kind = "assigned"
+	// KindProgress tells a student their complaint was approved at a stage
+	KindProgress Kind = "progress"
+	// KindReview tells the staff of the next stage a complaint awaits their review
+	KindReview Kind = "review"
+	// KindDeclined tells a student their complaint was declined
+	KindDeclined Kind = "declined"
+	// KindReminder tells the staff a complaint waits on that it is nearly due
+	KindReminder Kind = "reminder"
+	// KindEscalated tells HODs, and the staff who let it run late, that an overdue complaint
+	// was sent to the HOD queue
+	KindEscalated Kind = "escalated"
+	// KindComment tells the people in a complaint's discussion about a new comment they can read
+	KindComment Kind = "comment"
+)
+
+// Recipient is someone a notification is for
+type Recipient struct {
+	UserID string
+	Role   string
+	Name   string
+	Email  string
+}
+
+// Notification is one message to one recipient about an event
+type Notification struct {
+	Kind      Kind
+	Recipient Recipient
+	Event     models.Event
+}
+
+// Channel delivers notifications, for example by email. Deliver returns an error when the
+// notification should be delivered again later; the same notification may come twice, so a
+// channel should not repeat one it already delivered.
+type Channel interface {
+	Deliver(notification Notification) error
+}
+
+// Notifier works out who to tell about each complaint event and passes the notifications to
+// its channels
+type Notifier struct {
+	store    *models.Store
+	channels []Channel
+}
+
+// New returns a Notifier that looks people up in store. Subscribe its Handle method to the
+// store's events.
+func New(store *models.Store, channels ...Channel) *Notifier {
+	return &Notifier{store: store, channels: channels}
+}
+
+// Handle delivers the notifications for an event through every channel. It returns the errors
+// of the channels, so the store hands the event over again.
+func (n *Notifier) Handle(event models.Event) error {
+	var errs []error
+	for _, notification := range n.notifications(event) {
+		for _, channel := range n.channels {
+			if err := channel.Deliver(notification); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// notifications lists who is told about an event: the student about every step of their
+// complaint, the lecturer it is assigned to, and the reviewers of the stage it moves to
+func (n *Notifier) notifications(event models.Event) []Notification {
+	complaint := event.Complaint
+	var notifications []Notification
+	add := func(kind Kind, recipients ...Recipient) {
+		for _, recipient := range recipients {
+			notifications = append(notifications, Notification{Kind: kind, Recipient: recipient, Event: event})
+		}
+	}
+
+	switch event.Type {
+	case models.EventComplaintCreated:
+		add(KindReceived, n.student(complaint.RequestingStudent)...)
+		add(KindAssigned, n.lecturer(complaint.RespondingLecturer)...)
+	case models.EventComplaintReassigned:
+		add(KindAssigned, n.lecturer(complaint.RespondingLecturer)...)
+	case models.EventStatusChanged:
+		if complaint.Status == models.StatusDeclined {
+			add(KindDeclined, n.student(complaint.RequestingStudent)...)
+			break
+		}
+		add(KindProgress, n.student(complaint.RequestingStudent)...)
+		add(KindReview, n.reviewers(complaint)...)
+	case models.EventComplaintDueSoon:
+		add(KindReminder, n.reviewers(complaint)...)
+	case models.EventComplaintEscalated:
+		add(KindEscalated, n.hods(complaint)...)
+		add(KindEscalated, n.reviewers(complaint)...)
+	case models.EventCommentAdded:
+		add(KindComment, n.commentReaders(complaint, *event.Comment)...)
+	}
+	return notifications
+}
+
+// commentReaders returns who is told about a comment: the student, the lecturer and the
+// reviewers of the complaint's current stage, if their role can read it, but not its author
+func (n *Notifier) commentReaders(complaint models.Complaint, comment models.Comment) []Recipient {
+	candidates := n.student(complaint.RequestingStudent)
+	candidates = append(candidates, n.lecturer(complaint.RespondingLecturer)...)
+	if complaint.Status != models.StatusPending {
+		candidates = append(candidates, n.reviewers(complaint)...)
+	}
+
+	var recipients []Recipient
+	for _, recipient := range candidates {
+		if recipient.UserID == comment.Author.UserID || !canRead(comment, recipient.Role) {
+			continue
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients
+}
+
+func canRead(comment models.Comment, role string) bool {
+	for _, r := range comment.VisibleTo {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// hods returns the HODs of the department a complaint's course belongs to
+func (n *Notifier) hods(complaint models.Complaint) []Recipient {
+	department, err := n.store.Departments.GetDepartmentByCourseCode(complaint.CourseConcerned)
+	if err != nil {
+		logLookup("department of", complaint.CourseConcerned, err)
+		return nil
+	}
+
+	var recipients []Recipient
+	for _, userID := range department.HODs {
+		recipients = append(recipients, n.user(userID)...)
+	}
+	return recipients
+}
+
+// reviewers returns the staff who act on a complaint in its current status
+func (n *Notifier) reviewers(complaint models.Complaint) []Recipient {
+	var userIDs []string
+	switch complaint.Status {
+	case models.StatusPending:
+		return n.lecturer(complaint.RespondingLecturer)
+	case models.StatusApprovedByLecturer:
+		student, err := n.store.Students.GetStudentById(complaint.RequestingStudent)
+		if err != nil {
+			logLookup("student", complaint.RequestingStudent, err)
+			return nil
+		}
+		advisors, err := n.store.Advisors.GetAdvisorsForStudent(student)
+		if err != nil {
+			logLookup("advisors of", student.MatricNo, err)
+			return nil
+		}
+		for _, advisor := range advisors {
+			userIDs = append(userIDs, advisor.UserID)
+		}
+	case models.StatusApprovedByAdvisor:
+		return n.hods(complaint)
+	case models.StatusApprovedByHOD:
+		users, err := n.store.Users.GetUsersByRole(models.RoleSenate)
+		if err != nil {
+			logLookup("users with role", models.RoleSenate, err)
+			return nil
+		}
+		for _, user := range users {
+			userIDs = append(userIDs, user.UserID)
+		}
+	}
+
+	var recipients []Recipient
+	for _, userID := range userIDs {
+		recipients = append(recipients, n.user(userID)...)
+	}
+	return recipients
+}
+
+func (n *Notifier) student(matricNo string) []Recipient {
+	student, err := n.store.Students.GetStudentById(matricNo)
+	if err != nil && !errors.Is(err, models.ErrStudentNotFound) {
+		logLookup("student", matricNo, err)
+	}
+	return n.withUser(Recipient{
+		UserID: matricNo,
+		Role:   models.RoleStudent,
+		Name:   fullName(student.FirstName, student.LastName),
+		Email:  student.Email,
+	})
+}
+
+func (n *Notifier) lecturer(staffID string) []Recipient {
+	if staffID == "" {
+		return nil
+	}
+	lecturer, err := n.store.Lecturers.GetStaffById(staffID)
+	if err != nil && !errors.Is(err, models.ErrLecturerNotFound) {
+		logLookup("lecturer", staffID, err)
+	}
+	return n.withUser(Recipient{
+		UserID: staffID,
+		Role:   models.RoleLecturer,
+		Name:   fullName(lecturer.FirstName, lecturer.LastName),
+		Email:  lecturer.Email,
+	})
+}
+
+func (n *Notifier) user(userID string) []Recipient {
+	return n.withUser(Recipient{UserID: userID})
+}
+
+// withUser fills in what recipient lacks from the user account with the same ID
+func (n *Notifier) withUser(recipient Recipient) []Recipient {
+	user, err := n.store.Users.GetUserByUserID(recipient.UserID)
+	if err != nil {
+		if !errors.Is(err, models.ErrUserNotFound) {
+			logLookup("user", recipient.UserID, err)
+		}
+		if recipient.Role == "" {
+			return nil
+		}
+		return []Recipient{recipient}
+	}
+
+	if recipient.Role == "" {
+		recipient.Role = user.Role
+	}
+	if recipient.Name == "" {
+		recipient.Name = fullName(user.FirstName, user.LastName)
+	}
+	if recipient.Email == "" {
+		recipient.Email = user.Email
+	}
+	return []Recipient{recipient}
+}
+
+func fullName(first, last string) string {
+	return strings.TrimSpace(first + " " + last)
+}
+
+func logLookup(what, id string, err error) {
+	log.Printf("Unable to look up %s %s for notifications: %v", what, id, err)
+}
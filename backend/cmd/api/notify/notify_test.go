@@ -0,0 +1,136 @@
+package notify
+
+import (
+	"complaints/cmd/api/models"
+	"slices"
+	"testing"
+)
+
+func newTestNotifier() *Notifier {
+	store := models.NewMemoryStore(models.MemorySeed{
+		Users: []models.User{
+			{UserID: "adv1", Role: models.RoleAdvisor, Email: "adv1@uni.example"},
+			{UserID: "adv2", Role: models.RoleAdvisor, Email: "adv2@uni.example"},
+			{UserID: "hod1", Role: models.RoleHOD, FirstName: "Ngozi", LastName: "Eze", Email: "hod1@uni.example"},
+			{UserID: "sen1", Role: models.RoleSenate, Email: "sen1@uni.example"},
+			{UserID: "sen2", Role: models.RoleSenate},
+			{UserID: "lec2", Role: models.RoleLecturer, Email: "lec2-account@uni.example"},
+		},
+		Courses:     []models.Course{{CourseCode: "CSC101", Lecturers: []string{"lec1", "lec2"}}},
+		Students:    []models.Student{{MatricNo: "stu1", FirstName: "Ada", LastName: "Obi", Email: "ada@student.example", Program: "CS", Level: 100}},
+		Lecturers:   []models.Lecturer{{StaffID: "lec1", FirstName: "Tunde", Email: "lec1@uni.example"}, {StaffID: "lec2"}},
+		Departments: []models.Department{{Code: "CS", HODs: []string{"hod1"}, Courses: []string{"CSC101"}}},
+		Advisors: []models.Advisor{
+			{UserID: "adv1", Program: "CS", Levels: []int{100}},
+			{UserID: "adv2", Program: "CS", Levels: []int{200}},
+		},
+	})
+	return New(store)
+}
+
+// sent returns each notification for an event as "kind userID email"
+func sent(n *Notifier, event models.Event) []string {
+	var got []string
+	for _, notification := range n.notifications(event) {
+		got = append(got, string(notification.Kind)+" "+notification.Recipient.UserID+" "+notification.Recipient.Email)
+	}
+	slices.Sort(got)
+	return got
+}
+
+func TestNotificationRecipients(t *testing.T) {
+	n := newTestNotifier()
+
+	changed := func(status models.Status) models.Event {
+		event := testEvent(status)
+		event.Type = models.EventStatusChanged
+		return event
+	}
+	event := func(eventType models.EventType, status models.Status) models.Event {
+		event := testEvent(status)
+		event.Type = eventType
+		return event
+	}
+	reassigned := event(models.EventComplaintReassigned, models.StatusPending)
+	reassigned.Complaint.RespondingLecturer = "lec2"
+	staffComment := event(models.EventCommentAdded, models.StatusApprovedByAdvisor)
+	staffComment.Comment.Author = models.Actor{UserID: "hod1", Role: models.RoleHOD}
+	staffComment.Comment.VisibleTo = []string{models.RoleLecturer, models.RoleHOD}
+
+	tests := []struct {
+		name  string
+		event models.Event
+		want  []string
+	}{
+		{"created", event(models.EventComplaintCreated, models.StatusPending), []string{
+			"assigned lec1 lec1@uni.example",
+			"received stu1 ada@student.example",
+		}},
+		{"reassigned, with the email from the user account", reassigned, []string{
+			"assigned lec2 lec2-account@uni.example",
+		}},
+		{"approved by the lecturer", changed(models.StatusApprovedByLecturer), []string{
+			"progress stu1 ada@student.example",
+			"review adv1 adv1@uni.example",
+		}},
+		{"approved by the advisor", changed(models.StatusApprovedByAdvisor), []string{
+			"progress stu1 ada@student.example",
+			"review hod1 hod1@uni.example",
+		}},
+		{"approved by the HOD", changed(models.StatusApprovedByHOD), []string{
+			"progress stu1 ada@student.example",
+			"review sen1 sen1@uni.example",
+			"review sen2 ",
+		}},
+		{"approved by the Senate", changed(models.StatusApprovedBySenate), []string{
+			"progress stu1 ada@student.example",
+		}},
+		{"declined", changed(models.StatusDeclined), []string{
+			"declined stu1 ada@student.example",
+		}},
+		{"due soon", event(models.EventComplaintDueSoon, models.StatusApprovedByLecturer), []string{
+			"reminder adv1 adv1@uni.example",
+		}},
+		{"escalated", event(models.EventComplaintEscalated, models.StatusPending), []string{
+			"escalated hod1 hod1@uni.example",
+			"escalated lec1 lec1@uni.example",
+		}},
+		{"comment by the lecturer", event(models.EventCommentAdded, models.StatusPending), []string{
+			"comment stu1 ada@student.example",
+		}},
+		{"staff-only comment by the HOD", staffComment, []string{
+			"comment lec1 lec1@uni.example",
+		}},
+	}
+
+	for _, tt := range tests {
+		if got := sent(n, tt.event); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: notified %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRecipientDetails(t *testing.T) {
+	n := newTestNotifier()
+
+	tests := []struct {
+		recipients []Recipient
+		want       Recipient
+	}{
+		{n.student("stu1"), Recipient{UserID: "stu1", Role: models.RoleStudent, Name: "Ada Obi", Email: "ada@student.example"}},
+		{n.lecturer("lec1"), Recipient{UserID: "lec1", Role: models.RoleLecturer, Name: "Tunde", Email: "lec1@uni.example"}},
+		{n.user("hod1"), Recipient{UserID: "hod1", Role: models.RoleHOD, Name: "Ngozi Eze", Email: "hod1@uni.example"}},
+	}
+	for _, tt := range tests {
+		if len(tt.recipients) != 1 || tt.recipients[0] != tt.want {
+			t.Errorf("recipients = %+v, want %+v", tt.recipients, tt.want)
+		}
+	}
+
+	if got := n.user("nobody"); got != nil {
+		t.Errorf("user(nobody) = %+v, want no one", got)
+	}
+	if got := n.lecturer(""); got != nil {
+		t.Errorf("lecturer(\"\") = %+v, want no one", got)
+	}
+}
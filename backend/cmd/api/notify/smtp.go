@@ -0,0 +1,116 @@
+package notify
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPConfig locates the mail server and the address emails are sent from. Username and
+// Password are optional; when set, the server must offer STARTTLS unless it is on localhost.
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// smtpSender sends each message in its own SMTP session
+type smtpSender struct {
+	config  SMTPConfig
+	from    *mail.Address
+	timeout time.Duration
+}
+
+// NewSMTP returns a Sender that delivers through an SMTP server
+func NewSMTP(config SMTPConfig) (Sender, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("an SMTP host is required")
+	}
+	if config.Port == "" {
+		config.Port = "25"
+	}
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", config.From, err)
+	}
+	return &smtpSender{config: config, from: from, timeout: 30 * time.Second}, nil
+}
+
+func (s *smtpSender) Send(message Message) error {
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address %q: %w", message.To, err)
+	}
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(s.config.Host, s.config.Port), s.timeout)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(s.timeout))
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.config.Host}); err != nil {
+			return err
+		}
+	}
+	if s.config.Username != "" {
+		auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(s.from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(s.format(to, message)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// format builds a plain-text RFC 5322 message with CRLF line endings
+func (s *smtpSender) format(to *mail.Address, message Message) []byte {
+	id := make([]byte, 12)
+	rand.Read(id)
+	domain := s.from.Address[strings.LastIndex(s.from.Address, "@")+1:]
+
+	headers := []string{
+		"From: " + s.from.String(),
+		"To: " + to.String(),
+		"Subject: " + mime.QEncoding.Encode("utf-8", message.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Message-ID: <" + hex.EncodeToString(id) + "@" + domain + ">",
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Transfer-Encoding: 8bit",
+	}
+	body := strings.ReplaceAll(strings.ReplaceAll(message.Body, "\r\n", "\n"), "\n", "\r\n")
+	return []byte(strings.Join(headers, "\r\n") + "\r\n\r\n" + body)
+}
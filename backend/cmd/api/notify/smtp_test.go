@@ -0,0 +1,191 @@
+package notify
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+// smtpSink is an SMTP server that accepts one session and records the envelope and message.
+// rcptReply, if set, is its answer to RCPT TO instead of accepting the recipient.
+type smtpSink struct {
+	listener  net.Listener
+	rcptReply string
+	done      chan struct{}
+
+	// filled in once the session is over
+	from string
+	to   []string
+	data string
+	err  error
+}
+
+func startSMTPSink(t *testing.T, rcptReply string) *smtpSink {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	s := &smtpSink{listener: listener, rcptReply: rcptReply, done: make(chan struct{})}
+	go s.serve()
+	return s
+}
+
+func (s *smtpSink) serve() {
+	defer close(s.done)
+
+	conn, err := s.listener.Accept()
+	if err != nil {
+		s.err = err
+		return
+	}
+	defer conn.Close()
+
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 sink ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			s.err = err
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			text.PrintfLine("250-sink\r\n250 8BITMIME")
+		case "MAIL":
+			s.from = arg
+			text.PrintfLine("250 OK")
+		case "RCPT":
+			if s.rcptReply != "" {
+				text.PrintfLine("%s", s.rcptReply)
+				continue
+			}
+			s.to = append(s.to, arg)
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 go ahead")
+			// DotReader undoes the dot-stuffing and turns CRLF into LF
+			data, err := io.ReadAll(text.DotReader())
+			if err != nil {
+				s.err = err
+				return
+			}
+			s.data = string(data)
+			text.PrintfLine("250 queued")
+		case "RSET", "NOOP":
+			text.PrintfLine("250 OK")
+		case "QUIT":
+			text.PrintfLine("221 bye")
+			return
+		default:
+			text.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func (s *smtpSink) port() string {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return port
+}
+
+func TestSMTPSend(t *testing.T) {
+	sink := startSMTPSink(t, "")
+	sender, err := NewSMTP(SMTPConfig{Host: "127.0.0.1", Port: sink.port(), From: "Complaints Office <complaints@uni.example>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = sender.Send(Message{
+		To:      "Adébáyọ̀ Okafor <ade@student.example>",
+		Subject: "Your complaint about CSC101 is now Approved By Lecturer — next: advisor",
+		Body:    "Hello Adé,\n\n.a line that starts with a dot\nFollow it at https://app.example/student-dashboard\n",
+	})
+	<-sink.done
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if sink.err != nil {
+		t.Fatalf("sink: %v", sink.err)
+	}
+
+	// the body is 8bit, which the sink says it accepts
+	if sink.from != "FROM:<complaints@uni.example> BODY=8BITMIME" {
+		t.Errorf("MAIL %s, want FROM:<complaints@uni.example> BODY=8BITMIME", sink.from)
+	}
+	if len(sink.to) != 1 || sink.to[0] != "TO:<ade@student.example>" {
+		t.Errorf("RCPT %v, want TO:<ade@student.example>", sink.to)
+	}
+
+	message, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(sink.data)))
+	if err != nil {
+		t.Fatalf("unable to parse the message: %v\n%s", err, sink.data)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := message.Header.AddressList("To")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	headers := []struct {
+		name, got, want string
+	}{
+		{"Subject", subject, "Your complaint about CSC101 is now Approved By Lecturer — next: advisor"},
+		{"From", message.Header.Get("From"), `"Complaints Office" <complaints@uni.example>`},
+		{"To", to[0].Name + " <" + to[0].Address + ">", "Adébáyọ̀ Okafor <ade@student.example>"},
+		{"MIME-Version", message.Header.Get("MIME-Version"), "1.0"},
+		{"Content-Type", message.Header.Get("Content-Type"), "text/plain; charset=utf-8"},
+		{"Content-Transfer-Encoding", message.Header.Get("Content-Transfer-Encoding"), "8bit"},
+	}
+	for _, h := range headers {
+		if h.got != h.want {
+			t.Errorf("%s header = %q, want %q", h.name, h.got, h.want)
+		}
+	}
+	if id := message.Header.Get("Message-ID"); !strings.HasPrefix(id, "<") || !strings.HasSuffix(id, "@uni.example>") {
+		t.Errorf("Message-ID = %q, want one at the sender's domain", id)
+	}
+	if _, err := message.Header.Date(); err != nil {
+		t.Errorf("Date header: %v", err)
+	}
+
+	body, err := io.ReadAll(message.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Hello Adé,\n\n.a line that starts with a dot\nFollow it at https://app.example/student-dashboard\n"; string(body) != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+}
+
+func TestSMTPSendErrors(t *testing.T) {
+	sink := startSMTPSink(t, "550 5.1.1 no such mailbox")
+	sender, err := NewSMTP(SMTPConfig{Host: "127.0.0.1", Port: sink.port(), From: "complaints@uni.example"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sender.Send(Message{To: "nobody@uni.example", Subject: "s", Body: "b"}); err == nil || !strings.Contains(err.Error(), "no such mailbox") {
+		t.Errorf("Send() to a rejected recipient error = %v, want the server's refusal", err)
+	}
+	sink.listener.Close()
+	<-sink.done
+
+	if err := sender.Send(Message{To: "not an address", Subject: "s", Body: "b"}); err == nil || !strings.Contains(err.Error(), "invalid recipient") {
+		t.Errorf("Send() to an invalid address error = %v, want it refused", err)
+	}
+	if _, err := NewSMTP(SMTPConfig{Host: "127.0.0.1", From: "not an address"}); err == nil {
+		t.Error("NewSMTP() accepted an invalid sender address")
+	}
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"complaints/cmd/api/controllers"
 	"complaints/cmd/api/middleware"
+	"complaints/cmd/api/models"
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
@@ -11,32 +12,68 @@ import (
 func InitRoutes() http.Handler {
 	router := httprouter.New()
 
-	//serve static files
-	router.ServeFiles("/uploads/*filepath", http.Dir("./uploads"))
+	// uploaded files are only served through signed links; see controllers.DownloadFile for
+	// token-authenticated downloads
+	router.HandlerFunc(http.MethodGet, "/uploads/*key", controllers.GetUpload)
 	router.HandlerFunc(http.MethodPost, "/register", controllers.Register)
 	router.HandlerFunc(http.MethodPost, "/login", controllers.Login)
 
-	authHandler := func(handler http.HandlerFunc) http.HandlerFunc {
-		return middleware.Authenticate(handler).ServeHTTP
+	// authHandler authenticates the request and then only lets the given roles through
+	authHandler := func(handler http.HandlerFunc, roles ...string) http.HandlerFunc {
+		return middleware.Authenticate(middleware.RequireRole(roles...)(handler)).ServeHTTP
 	}
-	router.HandlerFunc(http.MethodPost, "/complaint", authHandler(controllers.NewComplaint))
-	router.HandlerFunc(http.MethodGet, "/complaint/:id", authHandler(controllers.GetComplaintByObjectID))
-	router.HandlerFunc(http.MethodGet, "/student-complaint/:id", authHandler(controllers.GetComplaintByCourseCode))
-	router.HandlerFunc(http.MethodGet, "/complaints/:id", authHandler(controllers.GetComplaintsByStudentID))
-	router.HandlerFunc(http.MethodGet, "/courses/:id", authHandler(controllers.GetCoursesByStudentID))
-	router.HandlerFunc(http.MethodGet, "/lecturer-courses/:id", authHandler(controllers.GetCoursesByStaffID))
-	router.HandlerFunc(http.MethodGet, "/staff-complaints/:id", authHandler(controllers.GetComplaintsByStaffID))
-	router.HandlerFunc(http.MethodGet, "/hod-complaints", authHandler(controllers.GetComplaintsForHOD))
-	router.HandlerFunc(http.MethodGet, "/senate-complaints", authHandler(controllers.GetComplaintsForSenate))
-	router.HandlerFunc(http.MethodGet, "/lecturer-complaints/:id", authHandler(controllers.GetComplaintsByCourseCode))
-	router.HandlerFunc(http.MethodPut, "/approved-by-lecturer/:id", authHandler(controllers.ChangeComplaintStatusByLecturer))
-	router.HandlerFunc(http.MethodPut, "/approved-by-advisor/:id", authHandler(controllers.ChangeComplaintStatusByAdvisor))
-	router.HandlerFunc(http.MethodPut, "/approved-by-hod/:id", authHandler(controllers.ChangeComplaintStatusByHOD))
-	router.HandlerFunc(http.MethodPut, "/approved-by-senate/:id", authHandler(controllers.ChangeComplaintStatusBySenate))
-	router.HandlerFunc(http.MethodPut, "/decline/:id", authHandler((controllers.DeclineRequest)))
-
-	//serve static files
-	// router.Handler(http.MethodGet, "/uploads/*filepath", http.StripPrefix("/uploads", http.FileServer(http.Dir("uploads"))))
+
+	student := models.RoleStudent
+	lecturer := models.RoleLecturer
+	advisor := models.RoleAdvisor
+	hod := models.RoleHOD
+	senate := models.RoleSenate
+	admin := models.RoleAdmin
+
+	router.HandlerFunc(http.MethodPost, "/complaint", authHandler(controllers.NewComplaint, student))
+	router.HandlerFunc(http.MethodGet, "/complaint/:id", authHandler(controllers.GetComplaintByObjectID, student, lecturer, advisor, hod, senate))
+	router.HandlerFunc(http.MethodGet, "/download/*key", authHandler(controllers.DownloadFile, student, lecturer, advisor, hod, senate))
+	router.HandlerFunc(http.MethodGet, "/complaint/:id/history", authHandler(controllers.GetComplaintHistory, student, lecturer, advisor, hod, senate))
+	router.HandlerFunc(http.MethodGet, "/complaint/:id/attachments", authHandler(controllers.GetAttachments, student, lecturer, advisor, hod, senate))
+	router.HandlerFunc(http.MethodPost, "/complaint/:id/attachments", authHandler(controllers.AddAttachments, student, lecturer, advisor, hod, senate))
+	router.HandlerFunc(http.MethodDelete, "/complaint/:id/attachments/:attachment", authHandler(controllers.RemoveAttachment, student, lecturer, advisor, hod, senate))
+	router.HandlerFunc(http.MethodGet, "/complaint/:id/comments", authHandler(controllers.GetComments, student, lecturer, hod, senate))
+	router.HandlerFunc(http.MethodPost, "/complaint/:id/comments", authHandler(controllers.AddComment, student, lecturer, hod, senate))
+	router.HandlerFunc(http.MethodGet, "/events", authHandler(controllers.StreamEvents, student, lecturer, advisor, hod, senate))
+	router.HandlerFunc(http.MethodGet, "/notifications", authHandler(controllers.GetNotifications, student, lecturer, advisor, hod, senate))
+	router.HandlerFunc(http.MethodGet, "/notifications/unread-count", authHandler(controllers.CountUnreadNotifications, student, lecturer, advisor, hod, senate))
+	router.HandlerFunc(http.MethodPost, "/notifications/read", authHandler(controllers.MarkNotificationsRead, student, lecturer, advisor, hod, senate))
+	router.HandlerFunc(http.MethodGet, "/student-complaint/:id", authHandler(controllers.GetComplaintByCourseCode, student))
+	router.HandlerFunc(http.MethodGet, "/complaints/:id", authHandler(controllers.GetComplaintsByStudentID, student))
+	router.HandlerFunc(http.MethodGet, "/courses/:id", authHandler(controllers.GetCoursesByStudentID, student))
+	router.HandlerFunc(http.MethodGet, "/lecturer-courses/:id", authHandler(controllers.GetCoursesByStaffID, lecturer))
+	router.HandlerFunc(http.MethodGet, "/staff-complaints/:id", authHandler(controllers.GetComplaintsByStaffID, lecturer))
+	router.HandlerFunc(http.MethodGet, "/advisor-complaints", authHandler(controllers.GetComplaintsForAdvisor, advisor))
+	router.HandlerFunc(http.MethodGet, "/hod-complaints", authHandler(controllers.GetComplaintsForHOD, hod))
+	router.HandlerFunc(http.MethodGet, "/senate-complaints", authHandler(controllers.GetComplaintsForSenate, senate))
+	router.HandlerFunc(http.MethodGet, "/lecturer-complaints/:id", authHandler(controllers.GetComplaintsByCourseCode, lecturer))
+
+	// the same handlers serve the caller's own resources without an ID in the path
+	router.HandlerFunc(http.MethodGet, "/me/student-complaint", authHandler(controllers.GetComplaintByCourseCode, student))
+	router.HandlerFunc(http.MethodGet, "/me/complaints", authHandler(controllers.GetComplaintsByStudentID, student))
+	router.HandlerFunc(http.MethodGet, "/me/courses", authHandler(controllers.GetCoursesByStudentID, student))
+	router.HandlerFunc(http.MethodGet, "/me/lecturer-courses", authHandler(controllers.GetCoursesByStaffID, lecturer))
+	router.HandlerFunc(http.MethodGet, "/me/staff-complaints", authHandler(controllers.GetComplaintsByStaffID, lecturer))
+	router.HandlerFunc(http.MethodGet, "/me/lecturer-complaints", authHandler(controllers.GetComplaintsByCourseCode, lecturer))
+
+	router.HandlerFunc(http.MethodPut, "/approved-by-lecturer/:id", authHandler(controllers.ChangeComplaintStatusByLecturer, lecturer))
+	router.HandlerFunc(http.MethodPut, "/approved-by-advisor/:id", authHandler(controllers.ChangeComplaintStatusByAdvisor, advisor))
+	router.HandlerFunc(http.MethodPut, "/approved-by-hod/:id", authHandler(controllers.ChangeComplaintStatusByHOD, hod))
+	router.HandlerFunc(http.MethodPut, "/approved-by-senate/:id", authHandler(controllers.ChangeComplaintStatusBySenate, senate))
+	router.HandlerFunc(http.MethodPut, "/decline/:id", authHandler(controllers.DeclineRequest, lecturer, advisor, hod, senate))
+	router.HandlerFunc(http.MethodPut, "/reassign/:id", authHandler(controllers.ReassignComplaint, hod))
+	router.HandlerFunc(http.MethodPut, "/reassign-lecturer/:id", authHandler(controllers.ReassignLecturerComplaints, hod))
+
+	router.HandlerFunc(http.MethodPost, "/users", authHandler(controllers.CreateUser, admin))
+	router.HandlerFunc(http.MethodGet, "/webhooks", authHandler(controllers.GetWebhooks, admin))
+	router.HandlerFunc(http.MethodPost, "/webhooks", authHandler(controllers.CreateWebhook, admin))
+	router.HandlerFunc(http.MethodDelete, "/webhooks/:id", authHandler(controllers.DeleteWebhook, admin))
+	router.HandlerFunc(http.MethodGet, "/webhooks/:id/deliveries", authHandler(controllers.GetWebhookDeliveries, admin))
 
 	return middleware.EnableCORS(router)
 }
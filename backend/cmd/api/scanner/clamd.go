@@ -0,0 +1,89 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// chunkSize is how much of the file is sent to clamd at a time
+const chunkSize = 64 << 10
+
+// clamd streams files to a ClamAV daemon with the INSTREAM command
+type clamd struct {
+	address string
+	timeout time.Duration
+}
+
+func (s *clamd) Name() string {
+	return Clamd
+}
+
+// Scan sends r to clamd as length-prefixed chunks followed by an empty chunk, then reads the
+// verdict: "stream: OK", "stream: <signature> FOUND" or an error such as the stream exceeding
+// the daemon's StreamMaxLength
+func (s *clamd) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	network, address := "tcp", s.address
+	if strings.HasPrefix(address, "unix:") {
+		network, address = "unix", strings.TrimPrefix(address, "unix:")
+	}
+
+	dialer := net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return Result{}, fmt.Errorf("unable to reach clamd: %w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return Result{}, err
+	}
+
+	buf := make([]byte, 4+chunkSize)
+	for {
+		n, err := r.Read(buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, err := conn.Write(buf[:4+n]); err != nil {
+				return Result{}, err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Result{}, err
+		}
+	}
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return Result{}, err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return Result{}, fmt.Errorf("unable to read clamd reply: %w", err)
+	}
+	return parseReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+func parseReply(reply string) (Result, error) {
+	verdict := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case verdict == "OK":
+		return Result{Clean: true}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return Result{Signature: strings.TrimSuffix(verdict, " FOUND")}, nil
+	}
+	return Result{}, fmt.Errorf("clamd could not scan the file: %s", reply)
+}
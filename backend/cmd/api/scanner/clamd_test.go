@@ -0,0 +1,235 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// stubClamd is a ClamAV daemon that answers one INSTREAM command with reply and records what
+// it was sent
+type stubClamd struct {
+	listener net.Listener
+	reply    string
+	done     chan struct{}
+
+	// filled in once the scan is done
+	command string
+	chunks  []int
+	data    []byte
+	err     error
+}
+
+func startClamd(t *testing.T, network, address, reply string) *stubClamd {
+	t.Helper()
+
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	d := &stubClamd{listener: listener, reply: reply, done: make(chan struct{})}
+	go d.serve()
+	return d
+}
+
+func (d *stubClamd) serve() {
+	defer close(d.done)
+
+	conn, err := d.listener.Accept()
+	if err != nil {
+		d.err = err
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	if d.command, d.err = r.ReadString(0); d.err != nil {
+		return
+	}
+	for {
+		var size uint32
+		if d.err = binary.Read(r, binary.BigEndian, &size); d.err != nil {
+			return
+		}
+		if size == 0 {
+			break
+		}
+		chunk := make([]byte, size)
+		if _, d.err = io.ReadFull(r, chunk); d.err != nil {
+			return
+		}
+		d.chunks = append(d.chunks, int(size))
+		d.data = append(d.data, chunk...)
+	}
+	conn.Write([]byte(d.reply + "\x00"))
+}
+
+// wait returns once the daemon has answered, failing the test if it could not read the stream
+func (d *stubClamd) wait(t *testing.T) {
+	t.Helper()
+
+	<-d.done
+	if d.err != nil {
+		t.Fatalf("stub clamd: %v", d.err)
+	}
+	if d.command != "zINSTREAM\x00" {
+		t.Errorf("command = %q, want zINSTREAM", d.command)
+	}
+}
+
+func TestClamdScan(t *testing.T) {
+	eicar := []byte(`X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`)
+
+	tests := []struct {
+		name   string
+		reply  string
+		file   io.Reader
+		sent   []byte
+		chunks []int
+		want   Result
+		err    string
+	}{
+		{
+			name:   "clean",
+			reply:  "stream: OK",
+			file:   bytes.NewReader([]byte("%PDF-1.7")),
+			sent:   []byte("%PDF-1.7"),
+			chunks: []int{8},
+			want:   Result{Clean: true},
+		},
+		{
+			name:   "infected",
+			reply:  "stream: Win.Test.EICAR_HDB-1 FOUND",
+			file:   bytes.NewReader(eicar),
+			sent:   eicar,
+			chunks: []int{len(eicar)},
+			want:   Result{Signature: "Win.Test.EICAR_HDB-1"},
+		},
+		{
+			name:  "too large for the daemon",
+			reply: "INSTREAM size limit exceeded. ERROR",
+			file:  bytes.NewReader(make([]byte, 10)),
+			sent:  make([]byte, 10),
+			err:   "INSTREAM size limit exceeded. ERROR",
+		},
+		{
+			name:   "split into chunks",
+			reply:  "stream: OK",
+			file:   bytes.NewReader(bytes.Repeat([]byte("a"), 2*chunkSize+100)),
+			sent:   bytes.Repeat([]byte("a"), 2*chunkSize+100),
+			chunks: []int{chunkSize, chunkSize, 100},
+			want:   Result{Clean: true},
+		},
+		{
+			name:   "data returned with EOF",
+			reply:  "stream: OK",
+			file:   iotest.DataErrReader(strings.NewReader("abc")),
+			sent:   []byte("abc"),
+			chunks: []int{3},
+			want:   Result{Clean: true},
+		},
+		{
+			name:  "empty file",
+			reply: "stream: OK",
+			file:  strings.NewReader(""),
+			want:  Result{Clean: true},
+		},
+	}
+
+	for _, tt := range tests {
+		daemon := startClamd(t, "tcp", "127.0.0.1:0", tt.reply)
+		scanner, err := New(Clamd, daemon.listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := scanner.Scan(context.Background(), tt.file)
+		daemon.wait(t)
+
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: Scan() error = %v, want one containing %q", tt.name, err, tt.err)
+			}
+		} else if err != nil || result != tt.want {
+			t.Errorf("%s: Scan() = %+v, %v, want %+v", tt.name, result, err, tt.want)
+		}
+		if !bytes.Equal(daemon.data, tt.sent) {
+			t.Errorf("%s: daemon got %d bytes, want %d", tt.name, len(daemon.data), len(tt.sent))
+		}
+		if tt.chunks != nil && !slices.Equal(daemon.chunks, tt.chunks) {
+			t.Errorf("%s: chunk lengths = %v, want %v", tt.name, daemon.chunks, tt.chunks)
+		}
+	}
+}
+
+func TestClamdScanUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "clamd.sock")
+	daemon := startClamd(t, "unix", socket, "stream: OK")
+
+	scanner, err := New(Clamd, "unix:"+socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := scanner.Scan(context.Background(), strings.NewReader("hello"))
+	daemon.wait(t)
+	if err != nil || !result.Clean {
+		t.Errorf("Scan() = %+v, %v, want a clean result", result, err)
+	}
+	if string(daemon.data) != "hello" {
+		t.Errorf("daemon got %q, want hello", daemon.data)
+	}
+}
+
+func TestClamdUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	scanner, err := New(Clamd, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := scanner.Scan(context.Background(), strings.NewReader("x")); err == nil || !strings.Contains(err.Error(), "unable to reach clamd") {
+		t.Errorf("Scan() error = %v, want it to say clamd is unreachable", err)
+	}
+}
+
+func TestParseReply(t *testing.T) {
+	tests := []struct {
+		reply string
+		want  Result
+		ok    bool
+	}{
+		{"stream: OK", Result{Clean: true}, true},
+		{"OK", Result{Clean: true}, true},
+		{"stream: Eicar-Signature FOUND", Result{Signature: "Eicar-Signature"}, true},
+		{"stream: Some Signature With Spaces FOUND", Result{Signature: "Some Signature With Spaces"}, true},
+		{"", Result{}, false},
+		{"stream:", Result{}, false},
+		{"stream: FOUND", Result{}, false},
+		{"stream: OK FOUND?", Result{}, false},
+		{"stream: OKAY", Result{}, false},
+		{"stream: lstat() failed: No such file or directory. ERROR", Result{}, false},
+		{"UNKNOWN COMMAND", Result{}, false},
+	}
+
+	for _, tt := range tests {
+		got, err := parseReply(tt.reply)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseReply(%q) = %+v, %v, want %+v (ok %v)", tt.reply, got, err, tt.want, tt.ok)
+		}
+	}
+}
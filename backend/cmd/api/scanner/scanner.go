@@ -0,0 +1,55 @@
+// Package scanner checks uploaded files for malware before they are stored.
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	None  = "none"
+	Clamd = "clamd"
+)
+
+// Result is the verdict on a scanned file
+type Result struct {
+	Clean bool
+	// Signature names what was found in a file that is not clean
+	Signature string
+}
+
+// Scanner checks the content of a file
+type Scanner interface {
+	// Name identifies the scanner in configuration and on scanned attachments
+	Name() string
+	// Scan reads r to the end and reports whether it is clean
+	Scan(ctx context.Context, r io.Reader) (Result, error)
+}
+
+// New returns the scanner with the given name. address locates the clamd daemon, either as
+// host:port or as unix:/path/to/clamd.sock.
+func New(name, address string) (Scanner, error) {
+	switch name {
+	case None:
+		return none{}, nil
+	case Clamd:
+		if address == "" {
+			return nil, fmt.Errorf("an address is required for the clamd scanner")
+		}
+		return &clamd{address: address, timeout: time.Minute}, nil
+	}
+	return nil, fmt.Errorf("unknown scanner %q", name)
+}
+
+// none accepts every file without looking at it
+type none struct{}
+
+func (none) Name() string {
+	return None
+}
+
+func (none) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	return Result{Clean: true}, nil
+}
@@ -0,0 +1,86 @@
+// Package sla works out when complaints are due at each stage of the workflow, and reminds
+// and escalates those that are running late
+package sla
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// dateLayout is how holidays are written in a calendar file
+const dateLayout = "2006-01-02"
+
+// Calendar tells business days from weekends and holidays, in the time zone its dates are in
+type Calendar struct {
+	holidays map[string]bool
+	location *time.Location
+}
+
+// NewCalendar returns a Calendar in location with the given holidays
+func NewCalendar(location *time.Location, holidays ...time.Time) Calendar {
+	c := Calendar{holidays: map[string]bool{}, location: location}
+	for _, holiday := range holidays {
+		c.holidays[holiday.Format(dateLayout)] = true
+	}
+	return c
+}
+
+// LoadCalendar reads holidays from a file with one date, as 2006-01-02, at the start of each
+// line; the rest of a line, after a space or tab, can name the holiday. Blank lines and lines
+// starting with # are skipped. An empty path gives a calendar with weekends only.
+func LoadCalendar(path string, location *time.Location) (Calendar, error) {
+	if path == "" {
+		return NewCalendar(location), nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return Calendar{}, fmt.Errorf("error reading holiday calendar: %w", err)
+	}
+	defer file.Close()
+
+	var holidays []time.Time
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		field := strings.Fields(text)[0]
+		date, err := time.ParseInLocation(dateLayout, field, location)
+		if err != nil {
+			return Calendar{}, fmt.Errorf("%s:%d: invalid holiday %q, expected a date such as 2024-12-25", path, line, field)
+		}
+		holidays = append(holidays, date)
+	}
+	if err := scanner.Err(); err != nil {
+		return Calendar{}, fmt.Errorf("error reading holiday calendar: %w", err)
+	}
+	return NewCalendar(location, holidays...), nil
+}
+
+// IsBusinessDay reports whether t falls on a weekday that is not a holiday
+func (c Calendar) IsBusinessDay(t time.Time) bool {
+	t = t.In(c.location)
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	return !c.holidays[t.Format(dateLayout)]
+}
+
+// AddBusinessDays returns the same time of day days business days after t. A start outside
+// business days counts from the next business day, so a complaint filed on a Saturday with one
+// day to go is due on Monday.
+func (c Calendar) AddBusinessDays(t time.Time, days int) time.Time {
+	t = t.In(c.location)
+	for days > 0 {
+		t = t.AddDate(0, 0, 1)
+		if c.IsBusinessDay(t) {
+			days--
+		}
+	}
+	return t
+}
@@ -0,0 +1,162 @@
+package sla
+
+import (
+	"complaints/cmd/api/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var lagos = time.FixedZone("WAT", 3600)
+
+func date(year int, month time.Month, day, hour int) time.Time {
+	return time.Date(year, month, day, hour, 0, 0, 0, lagos)
+}
+
+func TestAddBusinessDays(t *testing.T) {
+	// Christmas and Boxing Day 2024 fall on a Wednesday and Thursday
+	calendar := NewCalendar(lagos, date(2024, 12, 25, 0), date(2024, 12, 26, 0))
+
+	tests := []struct {
+		name  string
+		start time.Time
+		days  int
+		want  time.Time
+	}{
+		{"zero days", date(2024, 3, 4, 9), 0, date(2024, 3, 4, 9)},
+		{"within the week", date(2024, 3, 4, 9), 3, date(2024, 3, 7, 9)},
+		{"over a weekend", date(2024, 3, 7, 14), 2, date(2024, 3, 11, 14)},
+		{"from a Friday", date(2024, 3, 1, 9), 1, date(2024, 3, 4, 9)},
+		{"from a Saturday", date(2024, 3, 2, 9), 1, date(2024, 3, 4, 9)},
+		{"from a Sunday", date(2024, 3, 3, 9), 2, date(2024, 3, 5, 9)},
+		{"a full week", date(2024, 3, 4, 9), 5, date(2024, 3, 11, 9)},
+		{"over holidays", date(2024, 12, 24, 9), 1, date(2024, 12, 27, 9)},
+		{"over holidays and a weekend", date(2024, 12, 23, 9), 3, date(2024, 12, 30, 9)},
+		{"from a holiday", date(2024, 12, 25, 9), 1, date(2024, 12, 27, 9)},
+		// 23:30 UTC on Friday is already Saturday in Lagos
+		{"in the calendar's time zone", time.Date(2024, 3, 1, 23, 30, 0, 0, time.UTC), 1, date(2024, 3, 4, 0).Add(30 * time.Minute)},
+	}
+
+	for _, tt := range tests {
+		if got := calendar.AddBusinessDays(tt.start, tt.days); !got.Equal(tt.want) {
+			t.Errorf("%s: AddBusinessDays(%v, %d) = %v, want %v", tt.name, tt.start, tt.days, got, tt.want)
+		}
+	}
+}
+
+func TestIsBusinessDay(t *testing.T) {
+	calendar := NewCalendar(lagos, date(2024, 10, 1, 0))
+
+	tests := []struct {
+		day  time.Time
+		want bool
+	}{
+		{date(2024, 9, 30, 12), true},
+		{date(2024, 10, 1, 12), false},
+		{date(2024, 10, 2, 12), true},
+		{date(2024, 10, 5, 12), false},
+		{date(2024, 10, 6, 12), false},
+		// 23:30 UTC on the 30th is already the holiday in Lagos
+		{time.Date(2024, 9, 30, 23, 30, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		if got := calendar.IsBusinessDay(tt.day); got != tt.want {
+			t.Errorf("IsBusinessDay(%v) = %v, want %v", tt.day, got, tt.want)
+		}
+	}
+}
+
+func TestLoadCalendar(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		err      string
+		holidays []time.Time
+	}{
+		{
+			name:     "dates with names, comments and blank lines",
+			contents: "# public holidays\n2024-10-01 Independence Day\n\n  2024-12-25\tChristmas\n2024-12-26\n",
+			holidays: []time.Time{date(2024, 10, 1, 12), date(2024, 12, 25, 12), date(2024, 12, 26, 12)},
+		},
+		{
+			name:     "empty file",
+			contents: "",
+		},
+		{
+			name:     "invalid date",
+			contents: "2024-10-01\n01/10/2024 Independence Day\n",
+			err:      ":2: invalid holiday \"01/10/2024\"",
+		},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "holidays.txt")
+		if err := os.WriteFile(path, []byte(tt.contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		calendar, err := LoadCalendar(path, lagos)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: LoadCalendar() error = %v, want one containing %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: LoadCalendar() error = %v", tt.name, err)
+		}
+		for _, holiday := range tt.holidays {
+			if calendar.IsBusinessDay(holiday) {
+				t.Errorf("%s: %v is a business day, want a holiday", tt.name, holiday)
+			}
+		}
+		if !calendar.IsBusinessDay(date(2024, 10, 2, 12)) {
+			t.Errorf("%s: an ordinary Wednesday is not a business day", tt.name)
+		}
+	}
+
+	if _, err := LoadCalendar(filepath.Join(t.TempDir(), "missing.txt"), lagos); err == nil {
+		t.Error("LoadCalendar() of a missing file succeeded")
+	}
+	calendar, err := LoadCalendar("", lagos)
+	if err != nil || !calendar.IsBusinessDay(date(2024, 12, 25, 12)) || calendar.IsBusinessDay(date(2024, 12, 28, 12)) {
+		t.Errorf("LoadCalendar(\"\") = %v, want weekends only", err)
+	}
+}
+
+func TestPolicyDueAt(t *testing.T) {
+	policy := Policy{
+		Days: map[models.Status]int{
+			models.StatusPending:            3,
+			models.StatusApprovedByLecturer: 2,
+			models.StatusApprovedByHOD:      0,
+		},
+		Calendar: NewCalendar(lagos),
+	}
+	friday := date(2024, 3, 1, 10)
+
+	tests := []struct {
+		stage models.Status
+		want  *time.Time
+	}{
+		{models.StatusPending, ptr(date(2024, 3, 6, 10))},
+		{models.StatusApprovedByLecturer, ptr(date(2024, 3, 5, 10))},
+		{models.StatusApprovedByHOD, nil},
+		{models.StatusApprovedByAdvisor, nil},
+		{models.StatusDeclined, nil},
+	}
+
+	for _, tt := range tests {
+		got := policy.DueAt(tt.stage, friday)
+		if (got == nil) != (tt.want == nil) || got != nil && !got.Equal(*tt.want) {
+			t.Errorf("DueAt(%q) = %v, want %v", tt.stage, got, tt.want)
+		}
+	}
+}
+
+func ptr(t time.Time) *time.Time {
+	return &t
+}
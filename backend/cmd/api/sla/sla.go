@@ -0,0 +1,91 @@
+package sla
+
+import (
+	"complaints/cmd/api/models"
+	"errors"
+	"log"
+	"time"
+)
+
+// Policy gives each stage of the workflow a number of business days to be dealt with in
+type Policy struct {
+	// Days is the business days a complaint may spend in each status; a status left out or
+	// given 0 days has no deadline
+	Days     map[models.Status]int
+	Calendar Calendar
+}
+
+// DueAt returns when a complaint that reached stage at since is due to move on, or nil for a
+// stage without a deadline. It is a models.Deadlines.
+func (p Policy) DueAt(stage models.Status, since time.Time) *time.Time {
+	days := p.Days[stage]
+	if days <= 0 {
+		return nil
+	}
+	due := p.Calendar.AddBusinessDays(since, days)
+	return &due
+}
+
+// Escalates reports whether a complaint that misses its deadline at stage goes to the HOD
+// queue: those waiting on a lecturer or course advisor do, and the HOD may then approve or
+// decline them in their place. From the HOD stage on a late complaint is already with the HOD
+// or above, so it is only reminded.
+func Escalates(stage models.Status) bool {
+	return stage == models.StatusPending || stage == models.StatusApprovedByLecturer
+}
+
+// Worker periodically reminds the reviewers of complaints that are nearly due and escalates
+// those that are overdue. The reminders and escalations are store events, so they reach
+// people through the same notifications as every other change.
+type Worker struct {
+	complaints models.ComplaintStore
+	// remindBefore is how long before its deadline a complaint's reviewers are reminded
+	remindBefore time.Duration
+	interval     time.Duration
+}
+
+// NewWorker returns a Worker that checks complaints every interval and reminds reviewers
+// remindBefore their deadline
+func NewWorker(complaints models.ComplaintStore, remindBefore, interval time.Duration) *Worker {
+	return &Worker{complaints: complaints, remindBefore: remindBefore, interval: interval}
+}
+
+// Start checks complaints now and then every interval, in the background
+func (w *Worker) Start() {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			w.check(time.Now())
+			<-ticker.C
+		}
+	}()
+}
+
+func (w *Worker) check(now time.Time) {
+	complaints, err := w.complaints.GetComplaintsDueBy(now.Add(w.remindBefore))
+	if err != nil {
+		log.Println("Unable to get complaints due:", err)
+		return
+	}
+
+	for _, complaint := range complaints {
+		id := complaint.ID.Hex()
+		switch {
+		case !complaint.DueAt.After(now) && Escalates(complaint.Status):
+			err = w.complaints.EscalateComplaint(id, models.Escalation{
+				Stage: complaint.Status,
+				DueAt: complaint.DueAt,
+				At:    now,
+			})
+		case complaint.RemindedAt == nil:
+			err = w.complaints.RemindComplaint(id, complaint.Status)
+		default:
+			continue
+		}
+		// a complaint that moved on since it was read needs nothing more
+		if err != nil && !errors.Is(err, models.ErrInvalidTransition) {
+			log.Printf("Unable to handle the deadline of complaint %s: %v", id, err)
+		}
+	}
+}
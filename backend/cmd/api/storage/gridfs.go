@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// gridFSBucket names the bucket, and so the "<name>.files" and "<name>.chunks" collections
+const gridFSBucket = "Attachments"
+
+// gridFS keeps objects in MongoDB, using the key as the GridFS file ID
+type gridFS struct {
+	bucket *gridfs.Bucket
+}
+
+// NewGridFS returns a backend that stores objects in db
+func NewGridFS(db *mongo.Database) (Backend, error) {
+	bucket, err := gridfs.NewBucket(db, options.GridFSBucket().SetName(gridFSBucket))
+	if err != nil {
+		return nil, err
+	}
+	return &gridFS{bucket: bucket}, nil
+}
+
+func (s *gridFS) Name() string {
+	return GridFS
+}
+
+// Put replaces any existing object, since GridFS refuses a second file with the same ID
+func (s *gridFS) Put(ctx context.Context, key string, r io.Reader, contentType string) (int64, error) {
+	if !ValidKey(key) {
+		return 0, ErrInvalidKey
+	}
+	if err := s.Delete(ctx, key); err != nil && !errors.Is(err, ErrNotFound) {
+		return 0, err
+	}
+
+	upload := options.GridFSUpload().SetMetadata(bson.M{"content_type": contentType})
+	stream, err := s.bucket.OpenUploadStreamWithID(key, key, upload)
+	if err != nil {
+		return 0, err
+	}
+
+	size, err := io.Copy(stream, r)
+	if err != nil {
+		stream.Abort()
+		return 0, err
+	}
+	return size, stream.Close()
+}
+
+func (s *gridFS) Get(ctx context.Context, key string) (*Object, error) {
+	if !ValidKey(key) {
+		return nil, ErrInvalidKey
+	}
+
+	stream, err := s.bucket.OpenDownloadStream(key)
+	if err != nil {
+		if errors.Is(err, gridfs.ErrFileNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	file := stream.GetFile()
+	var metadata struct {
+		ContentType string `bson:"content_type"`
+	}
+	if file.Metadata != nil {
+		_ = bson.Unmarshal(file.Metadata, &metadata)
+	}
+	return &Object{ReadCloser: stream, Size: file.Length, ContentType: metadata.ContentType}, nil
+}
+
+func (s *gridFS) Delete(ctx context.Context, key string) error {
+	if !ValidKey(key) {
+		return ErrInvalidKey
+	}
+
+	err := s.bucket.DeleteContext(ctx, key)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
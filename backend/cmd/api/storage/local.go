@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// local keeps objects as files under a directory. It only suits a single API instance, or
+// several sharing a network file system.
+type local struct {
+	dir string
+}
+
+// NewLocal returns a backend that stores objects under dir, creating it if needed
+func NewLocal(dir string) (Backend, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &local{dir: dir}, nil
+}
+
+func (s *local) Name() string {
+	return Local
+}
+
+func (s *local) path(key string) (string, error) {
+	if !ValidKey(key) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// Put writes to a temporary file next to the destination and renames it into place once
+// complete, so readers never see a partly written file
+func (s *local) Put(ctx context.Context, key string, r io.Reader, contentType string) (int64, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+
+	dir := filepath.Dir(name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return 0, err
+	}
+	// removing the temporary file fails harmlessly once it has been renamed
+	defer os.Remove(tmp.Name())
+
+	size, err := io.Copy(tmp, r)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+func (s *local) Get(ctx context.Context, key string) (*Object, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, ErrNotFound
+	}
+	return &Object{ReadCloser: file, Size: info.Size()}, nil
+}
+
+func (s *local) Delete(ctx context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
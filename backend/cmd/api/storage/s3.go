@@ -0,0 +1,256 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Config locates an S3-compatible bucket such as one on AWS or a MinIO server
+type S3Config struct {
+	// Endpoint is the server's base URL, e.g. "https://s3.eu-west-1.amazonaws.com" or "http://localhost:9000"
+	Endpoint  string
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+}
+
+// s3 talks to the bucket over the REST API with path-style URLs and Signature Version 4, which
+// AWS and MinIO both accept, so no SDK is needed for the few calls it makes
+type s3 struct {
+	config S3Config
+	client *http.Client
+}
+
+// NewS3 returns a backend that stores objects in an S3-compatible bucket
+func NewS3(config S3Config) (Backend, error) {
+	if config.Endpoint == "" || config.Bucket == "" {
+		return nil, errors.New("an S3 endpoint and bucket are required")
+	}
+	if config.AccessKey == "" || config.SecretKey == "" {
+		return nil, errors.New("S3 access and secret keys are required")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	config.Endpoint = strings.TrimRight(config.Endpoint, "/")
+
+	return &s3{config: config, client: &http.Client{Timeout: time.Minute}}, nil
+}
+
+func (s *s3) Name() string {
+	return S3
+}
+
+// Put reads the whole object into memory, since the signature covers a hash of the body.
+// Uploads are already limited to a few megabytes by the handlers.
+func (s *s3) Put(ctx context.Context, key string, r io.Reader, contentType string) (int64, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := s.request(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return 0, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.do(req, body)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return int64(len(body)), nil
+}
+
+func (s *s3) Get(ctx context.Context, key string) (*Object, error) {
+	req, err := s.request(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Object{
+		ReadCloser:  resp.Body,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+	}, nil
+}
+
+// Delete reports ErrNotFound only if the object was missing beforehand, since S3 itself
+// succeeds either way
+func (s *s3) Delete(ctx context.Context, key string) error {
+	head, err := s.request(ctx, http.MethodHead, key, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(head, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	req, err := s.request(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	resp, err = s.do(req, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// request builds an unsigned request for the object under key
+func (s *s3) request(ctx context.Context, method, key string, body []byte) (*http.Request, error) {
+	if !ValidKey(key) {
+		return nil, ErrInvalidKey
+	}
+
+	objectURL := s.config.Endpoint + "/" + escapePath(s.config.Bucket) + "/" + escapePath(key)
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	return http.NewRequestWithContext(ctx, method, objectURL, reader)
+}
+
+// do signs and sends req, turning error responses into errors
+func (s *s3) do(req *http.Request, body []byte) (*http.Response, error) {
+	signV4(req, body, s.config, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("s3 %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, bytes.TrimSpace(message))
+	}
+	return resp, nil
+}
+
+// signV4 adds the headers and Authorization of an AWS Signature Version 4 request, signing
+// the host, every x-amz-* header and the content type and range if set
+func signV4(req *http.Request, body []byte, config S3Config, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" || lower == "range" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + config.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+config.SecretKey), day)
+	key = hmacSHA256(key, config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		config.AccessKey, scope, signedHeaders, signature))
+}
+
+func canonicalQuery(values url.Values) string {
+	var pairs []string
+	for name, list := range values {
+		for _, value := range list {
+			pairs = append(pairs, escape(name)+"="+escape(value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// escapePath escapes each segment of a slash-separated path as SigV4 expects
+func escapePath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		parts[i] = escape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// escape percent-encodes everything except the RFC 3986 unreserved characters
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
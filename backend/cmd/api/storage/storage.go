@@ -0,0 +1,61 @@
+// Package storage keeps the files uploaded with complaints, on local disk, in an
+// S3-compatible bucket or in MongoDB GridFS.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+const (
+	Local  = "local"
+	S3     = "s3"
+	GridFS = "gridfs"
+)
+
+var (
+	// ErrNotFound is returned when no object is stored under a key
+	ErrNotFound = errors.New("stored file not found")
+	// ErrInvalidKey is returned for keys that are empty, absolute or climb out of their directory
+	ErrInvalidKey = errors.New("invalid storage key")
+)
+
+// Backend stores objects under slash-separated keys such as "<complaint ID>/<file name>"
+type Backend interface {
+	// Name identifies the backend in configuration
+	Name() string
+	// Put stores everything read from r under key, replacing any object already there, and
+	// returns the number of bytes stored
+	Put(ctx context.Context, key string, r io.Reader, contentType string) (int64, error)
+	// Get opens the object stored under key; the caller must close it
+	Get(ctx context.Context, key string) (*Object, error)
+	// Delete removes the object stored under key
+	Delete(ctx context.Context, key string) error
+}
+
+// Object is a stored file being read back. Size and ContentType are left empty when the
+// backend does not know them.
+type Object struct {
+	io.ReadCloser
+	Size        int64
+	ContentType string
+}
+
+// ValidKey reports whether key is a relative path that stays inside the storage root
+func ValidKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.ContainsAny(key, "\\\x00") {
+		return false
+	}
+	if path.Clean(key) != key {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
@@ -5,11 +5,16 @@ import (
 	"net/http"
 )
 
+// WriteJSON writes data as JSON under the key wrap, or as it is when wrap is empty
 func WriteJSON(w http.ResponseWriter, status int, data interface{}, wrap string) error {
-	wrapper := make(map[string]interface{})
-	wrapper[wrap] = data
+	var payload interface{} = data
+	if wrap != "" {
+		wrapper := make(map[string]interface{})
+		wrapper[wrap] = data
+		payload = wrapper
+	}
 
-	js, err := json.Marshal(wrapper)
+	js, err := json.Marshal(payload)
 	if err != nil {
 		return err
 	}
@@ -21,7 +26,13 @@ func WriteJSON(w http.ResponseWriter, status int, data interface{}, wrap string)
 	return nil
 }
 
-func ErrorJSON(w http.ResponseWriter, err error) {
+// ErrorJSON writes err as a JSON error message, with status 400 unless another status is given
+func ErrorJSON(w http.ResponseWriter, err error, status ...int) {
+	statusCode := http.StatusBadRequest
+	if len(status) > 0 {
+		statusCode = status[0]
+	}
+
 	type JSONError struct {
 		Message string `json:"message"`
 	}
@@ -30,5 +41,5 @@ func ErrorJSON(w http.ResponseWriter, err error) {
 		Message: err.Error(),
 	}
 
-	WriteJSON(w, http.StatusBadRequest, theError, "error")
+	WriteJSON(w, statusCode, theError, "error")
 }
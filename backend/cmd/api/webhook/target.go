@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"sync/atomic"
+	"syscall"
+)
+
+// ErrUnsafeTarget is returned for a webhook URL that is not https or that reaches a loopback,
+// private, link-local or otherwise internal address
+var ErrUnsafeTarget = errors.New("webhook URL must be https and reach a public address")
+
+// allowPrivate is set to let webhooks reach internal addresses over http, for trying them out
+// against a receiver on the same machine or network
+var allowPrivate atomic.Bool
+
+// SetAllowPrivate lets webhook URLs be http and reach loopback, private and other internal
+// addresses. It is meant for local testing and is off by default.
+func SetAllowPrivate(allow bool) {
+	allowPrivate.Store(allow)
+}
+
+// allowedScheme reports whether deliveries may be sent to a URL with scheme
+func allowedScheme(scheme string) bool {
+	return scheme == "https" || (scheme == "http" && allowPrivate.Load())
+}
+
+// internalPrefixes are the special-purpose ranges that netip.Addr's own checks leave out
+var internalPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64, which can reach IPv4 addresses inside the network
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local-use NAT64
+	netip.MustParsePrefix("100::/64"),        // discard-only
+	netip.MustParsePrefix("2001::/32"),       // Teredo, which embeds an IPv4 address
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+	netip.MustParsePrefix("2002::/16"),       // 6to4, likewise
+	netip.MustParsePrefix("fec0::/10"),       // deprecated site-local
+}
+
+// isPublic reports whether deliveries may be sent to addr: loopback, private, link-local,
+// multicast and unspecified addresses are left out as well as internalPrefixes
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range internalPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckURL returns an error wrapping ErrUnsafeTarget unless raw is an absolute https URL whose
+// host resolves only to public addresses, or any absolute http or https URL once
+// SetAllowPrivate is on. Deliveries are checked again when they connect, as the host may
+// resolve differently by then.
+func CheckURL(ctx context.Context, raw string) error {
+	target, err := url.Parse(raw)
+	if err != nil || !allowedScheme(target.Scheme) || target.Hostname() == "" {
+		return fmt.Errorf("%w: %q is not an absolute https URL", ErrUnsafeTarget, raw)
+	}
+	if allowPrivate.Load() {
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", target.Hostname())
+	if err != nil {
+		return fmt.Errorf("unable to resolve webhook host %q: %w", target.Hostname(), err)
+	}
+	for _, addr := range addrs {
+		if !isPublic(addr) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrUnsafeTarget, target.Hostname(), addr.Unmap())
+		}
+	}
+	return nil
+}
+
+// checkDial refuses connections to anything but public addresses. It runs after the host is
+// resolved, so a name that later resolves to an internal address is caught too. Once
+// SetAllowPrivate is on it lets every connection through.
+func checkDial(network, address string, _ syscall.RawConn) error {
+	if allowPrivate.Load() {
+		return nil
+	}
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: cannot parse %q", ErrUnsafeTarget, address)
+	}
+	if !isPublic(addrPort.Addr()) {
+		return fmt.Errorf("%w: refusing to connect to %s", ErrUnsafeTarget, addrPort.Addr().Unmap())
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"1.1.1.1", true},
+		{"2606:4700:4700::1111", true},
+		{"::ffff:8.8.8.8", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fc00::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"100.64.0.1", false},
+		{"192.0.2.10", false},
+		{"240.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+		{"2002:a00:1::", false},
+		{"2001:db8::1", false},
+	}
+
+	for _, tt := range tests {
+		if got := isPublic(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("isPublic(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		safe bool
+	}{
+		{"https://8.8.8.8/hook", true},
+		{"https://[2606:4700:4700::1111]:8443/hook", true},
+		{"http://8.8.8.8/hook", false},
+		{"ftp://8.8.8.8/hook", false},
+		{"https:///hook", false},
+		{"8.8.8.8/hook", false},
+		{"https://127.0.0.1/hook", false},
+		{"https://[::1]/hook", false},
+		{"https://10.0.0.5:8443/hook", false},
+		{"https://169.254.169.254/latest/meta-data", false},
+		{"https://[::ffff:192.168.0.1]/hook", false},
+	}
+
+	for _, tt := range tests {
+		err := CheckURL(context.Background(), tt.url)
+		if tt.safe && err != nil {
+			t.Errorf("CheckURL(%q) = %v, want nil", tt.url, err)
+		}
+		if !tt.safe && !errors.Is(err, ErrUnsafeTarget) {
+			t.Errorf("CheckURL(%q) = %v, want ErrUnsafeTarget", tt.url, err)
+		}
+	}
+}
+
+func TestCheckDial(t *testing.T) {
+	tests := []struct {
+		address string
+		safe    bool
+	}{
+		{"8.8.8.8:443", true},
+		{"[2606:4700:4700::1111]:443", true},
+		{"127.0.0.1:443", false},
+		{"[::1]:443", false},
+		{"192.168.0.10:8443", false},
+		{"localhost:443", false},
+	}
+
+	for _, tt := range tests {
+		err := checkDial("tcp", tt.address, nil)
+		if tt.safe && err != nil {
+			t.Errorf("checkDial(%q) = %v, want nil", tt.address, err)
+		}
+		if !tt.safe && !errors.Is(err, ErrUnsafeTarget) {
+			t.Errorf("checkDial(%q) = %v, want ErrUnsafeTarget", tt.address, err)
+		}
+	}
+}
+
+// allowPrivateForTest turns SetAllowPrivate on until the test ends
+func allowPrivateForTest(t *testing.T) {
+	SetAllowPrivate(true)
+	t.Cleanup(func() { SetAllowPrivate(false) })
+}
+
+func TestAllowPrivate(t *testing.T) {
+	allowPrivateForTest(t)
+
+	urls := []struct {
+		url  string
+		safe bool
+	}{
+		{"http://127.0.0.1:8080/hook", true},
+		{"https://localhost/hook", true},
+		{"http://10.0.0.5/hook", true},
+		{"ftp://127.0.0.1/hook", false},
+		{"http:///hook", false},
+	}
+	for _, tt := range urls {
+		err := CheckURL(context.Background(), tt.url)
+		if tt.safe && err != nil {
+			t.Errorf("CheckURL(%q) = %v, want nil", tt.url, err)
+		}
+		if !tt.safe && !errors.Is(err, ErrUnsafeTarget) {
+			t.Errorf("CheckURL(%q) = %v, want ErrUnsafeTarget", tt.url, err)
+		}
+	}
+
+	for _, address := range []string{"127.0.0.1:8080", "[::1]:443", "192.168.0.10:8443"} {
+		if err := checkDial("tcp", address, nil); err != nil {
+			t.Errorf("checkDial(%q) = %v, want nil", address, err)
+		}
+	}
+}
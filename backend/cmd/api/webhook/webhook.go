@@ -0,0 +1,244 @@
+// Package webhook delivers complaint events to the URLs outside systems subscribe with
+package webhook
+
+import (
+	"bytes"
+	"complaints/cmd/api/models"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Headers sent with every delivery. The delivery ID is the event's, so it stays the same across
+// retries and receivers can use it to ignore duplicates.
+const (
+	HeaderEvent     = "X-Complaints-Event"
+	HeaderDelivery  = "X-Complaints-Delivery"
+	HeaderTimestamp = "X-Complaints-Timestamp"
+	HeaderSignature = "X-Complaints-Signature"
+)
+
+// timeout bounds how long a receiver has to answer a delivery
+const timeout = 10 * time.Second
+
+// workers is how many deliveries are sent at once
+const workers = 4
+
+// Sign returns the signature header value for a delivery: "sha256=" and the hex-encoded
+// HMAC-SHA256, keyed with the webhook's secret, of the timestamp, a dot and the body
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// queue is the job queue deliveries wait in
+const queue = "webhook"
+
+// Dispatcher posts events to the webhooks subscribed to them. Each delivery is a job in the
+// store, so it survives a restart; a failed delivery is tried again after a backoff that doubles
+// each time, until it succeeds or runs out of attempts. Every attempt is logged to the store.
+type Dispatcher struct {
+	webhooks    models.WebhookStore
+	jobs        models.JobStore
+	worker      *models.JobWorker
+	client      *http.Client
+	maxAttempts int
+	backoff     time.Duration
+}
+
+// delivery is the payload of a delivery job: the event, as it is posted, for one webhook
+type delivery struct {
+	WebhookID primitive.ObjectID `json:"webhook_id"`
+	EventID   primitive.ObjectID `json:"event_id"`
+	EventType models.EventType   `json:"event_type"`
+	Body      json.RawMessage    `json:"body"`
+}
+
+// New returns a Dispatcher that makes up to maxAttempts attempts per delivery, waiting backoff
+// after the first failure, and starts sending the deliveries queued in jobs
+func New(webhooks models.WebhookStore, jobs models.JobStore, maxAttempts int, backoff time.Duration) *Dispatcher {
+	d := &Dispatcher{
+		webhooks:    webhooks,
+		jobs:        jobs,
+		client:      newClient(),
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+	}
+	d.worker = models.NewJobWorker(jobs, queue, d.send)
+	d.worker.Start(workers)
+	return d
+}
+
+// Handle queues a delivery of an event to every webhook subscribed to its type; subscribe it to
+// the store. It only returns once the deliveries are saved, so the event is handled again if
+// they could not be.
+func (d *Dispatcher) Handle(event models.Event) error {
+	webhooks, err := d.webhooks.GetWebhooks()
+	if err != nil {
+		return fmt.Errorf("unable to get webhooks: %w", err)
+	}
+
+	var body []byte
+	for _, webhook := range webhooks {
+		if !subscribed(webhook, event.Type) {
+			continue
+		}
+		if body == nil {
+			if body, err = json.Marshal(event); err != nil {
+				return fmt.Errorf("unable to encode webhook event: %w", err)
+			}
+		}
+		payload, err := json.Marshal(delivery{WebhookID: webhook.ID, EventID: event.ID, EventType: event.Type, Body: body})
+		if err != nil {
+			return fmt.Errorf("unable to encode webhook delivery: %w", err)
+		}
+		// one delivery per webhook and event, however often the event is handled
+		key := webhook.ID.Hex() + "/" + event.ID.Hex()
+		if err := d.jobs.QueueJob(models.NewJob(queue, key, payload)); err != nil {
+			return fmt.Errorf("unable to queue webhook delivery: %w", err)
+		}
+	}
+	d.worker.Wake()
+	return nil
+}
+
+func subscribed(webhook models.Webhook, eventType models.EventType) bool {
+	for _, t := range webhook.Events {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// send makes an attempt at a delivery job. The webhook is read again for each attempt, so a
+// deleted webhook gets no more deliveries and a changed URL or secret is picked up.
+func (d *Dispatcher) send(job models.Job) {
+	var payload delivery
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		log.Printf("Unable to decode webhook delivery %s, dropping it: %v", job.ID.Hex(), err)
+		d.complete(job)
+		return
+	}
+
+	webhook, err := d.webhooks.GetWebhook(payload.WebhookID)
+	if errors.Is(err, models.ErrWebhookNotFound) {
+		d.complete(job)
+		return
+	}
+	if err != nil {
+		log.Println("Unable to get webhook:", err)
+		d.retry(job, time.Now().Add(d.backoff))
+		return
+	}
+
+	statusCode, err := d.post(webhook, payload)
+	record := models.WebhookDelivery{
+		WebhookID:  webhook.ID,
+		EventID:    payload.EventID,
+		EventType:  payload.EventType,
+		URL:        webhook.URL,
+		Attempt:    job.Attempt,
+		StatusCode: statusCode,
+		Delivered:  err == nil,
+		At:         time.Now(),
+	}
+	if err != nil {
+		record.Error = err.Error()
+		if job.Attempt < d.maxAttempts {
+			next := record.At.Add(models.Backoff(d.backoff, job.Attempt))
+			record.NextAttemptAt = &next
+		}
+	}
+	if err := d.webhooks.RecordDelivery(record); err != nil {
+		log.Println("Unable to record webhook delivery:", err)
+	}
+
+	if record.NextAttemptAt != nil {
+		d.retry(job, *record.NextAttemptAt)
+		return
+	}
+	d.complete(job)
+}
+
+func (d *Dispatcher) retry(job models.Job, at time.Time) {
+	if err := d.jobs.RetryJob(job.ID, at); err != nil {
+		log.Println("Unable to reschedule webhook delivery:", err)
+	}
+}
+
+func (d *Dispatcher) complete(job models.Job) {
+	if err := d.jobs.CompleteJob(job.ID); err != nil {
+		log.Println("Unable to complete webhook delivery:", err)
+	}
+}
+
+// newClient returns the client deliveries are posted with. It only connects to public addresses
+// and only follows redirects to https URLs, so a webhook cannot be pointed at internal services,
+// unless SetAllowPrivate is on.
+func newClient() *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: checkDial}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(request *http.Request, via []*http.Request) error {
+			if !allowedScheme(request.URL.Scheme) {
+				return fmt.Errorf("%w: redirected to %s", ErrUnsafeTarget, request.URL.Redacted())
+			}
+			if len(via) >= 5 {
+				return errors.New("stopped after 5 redirects")
+			}
+			return nil
+		},
+	}
+}
+
+// post sends a delivery and returns the receiver's status code. Anything but a 2xx response is
+// an error.
+func (d *Dispatcher) post(webhook models.Webhook, payload delivery) (int, error) {
+	target, err := url.Parse(webhook.URL)
+	if err != nil || !allowedScheme(target.Scheme) {
+		return 0, fmt.Errorf("%w: %q is not an https URL", ErrUnsafeTarget, webhook.URL)
+	}
+	request, err := http.NewRequest(http.MethodPost, target.String(), bytes.NewReader(payload.Body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "complaints-webhook/1")
+	request.Header.Set(HeaderEvent, string(payload.EventType))
+	request.Header.Set(HeaderDelivery, payload.EventID.Hex())
+	request.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, payload.Body))
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("receiver answered %s", response.Status)
+	}
+	return response.StatusCode, nil
+}
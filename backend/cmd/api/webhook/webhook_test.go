@@ -0,0 +1,197 @@
+package webhook
+
+import (
+	"complaints/cmd/api/models"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		secret    string
+		timestamp int64
+		body      string
+		want      string
+	}{
+		{"whsec_test", 1700000000, `{"type":"complaint.created"}`, "sha256=0402795ae62b0b0b107591ea26e3ccdd8ed3f304ade07d700280ed094f761ae4"},
+		{"", 0, "", "sha256=b849d5a581847b281957065739df36df2463d1977ea8d6e1e4e6cf33fadc68c3"},
+	}
+
+	for _, tt := range tests {
+		if got := Sign(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+			t.Errorf("Sign(%q, %d, %q) = %q, want %q", tt.secret, tt.timestamp, tt.body, got, tt.want)
+		}
+	}
+}
+
+// receiver is a webhook endpoint that answers each delivery with the next of its statuses
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   []string
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, request *http.Request) {
+	body, _ := io.ReadAll(request.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, request)
+	r.bodies = append(r.bodies, string(body))
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+// waitForDeliveries waits until n attempts of webhookID are recorded and returns them oldest first
+func waitForDeliveries(t *testing.T, webhooks models.WebhookStore, webhookID primitive.ObjectID, n int) []models.WebhookDelivery {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		deliveries, err := webhooks.GetDeliveries(webhookID, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(deliveries) >= n {
+			for i, j := 0, len(deliveries)-1; i < j; i, j = i+1, j-1 {
+				deliveries[i], deliveries[j] = deliveries[j], deliveries[i]
+			}
+			return deliveries
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d delivery attempts, want %d", len(deliveries), n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDispatcherRetriesUntilDelivered(t *testing.T) {
+	receiver := &receiver{statuses: []int{http.StatusInternalServerError}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	// the test server listens on loopback over http
+	allowPrivateForTest(t)
+
+	store := models.NewMemoryStore(models.MemorySeed{})
+	dispatcher := New(store.Webhooks, store.Jobs, 3, time.Millisecond)
+
+	subscribed := models.Webhook{ID: primitive.NewObjectID(), URL: server.URL + "/hook", Secret: "s3cret", Events: []models.EventType{models.EventComplaintCreated}}
+	other := models.Webhook{ID: primitive.NewObjectID(), URL: server.URL + "/other", Events: []models.EventType{models.EventCommentAdded}}
+	for _, webhook := range []models.Webhook{subscribed, other} {
+		if _, err := store.Webhooks.CreateWebhook(webhook); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	event := models.Event{ID: primitive.NewObjectID(), Type: models.EventComplaintCreated, At: time.Now()}
+	// handling the event twice, as happens when another subscriber failed, queues one delivery
+	for i := 0; i < 2; i++ {
+		if err := dispatcher.Handle(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deliveries := waitForDeliveries(t, store.Webhooks, subscribed.ID, 2)
+	tests := []struct {
+		attempt   int
+		status    int
+		delivered bool
+		retried   bool
+	}{
+		{1, http.StatusInternalServerError, false, true},
+		{2, http.StatusOK, true, false},
+	}
+	for i, tt := range tests {
+		delivery := deliveries[i]
+		if delivery.Attempt != tt.attempt || delivery.StatusCode != tt.status || delivery.Delivered != tt.delivered || (delivery.NextAttemptAt != nil) != tt.retried {
+			t.Errorf("attempt %d = %+v, want status %d, delivered %v, retried %v", i+1, delivery, tt.status, tt.delivered, tt.retried)
+		}
+		if delivery.EventID != event.ID {
+			t.Errorf("attempt %d is for event %s, want %s", i+1, delivery.EventID.Hex(), event.ID.Hex())
+		}
+	}
+
+	// give a stray third delivery the chance to arrive before counting
+	time.Sleep(50 * time.Millisecond)
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+	if len(receiver.requests) != 2 {
+		t.Fatalf("receiver got %d requests, want 2", len(receiver.requests))
+	}
+	for i, request := range receiver.requests {
+		if request.URL.Path != "/hook" {
+			t.Errorf("request %d went to %s, want /hook", i+1, request.URL.Path)
+		}
+		if request.Header.Get(HeaderDelivery) != event.ID.Hex() || request.Header.Get(HeaderEvent) != string(event.Type) {
+			t.Errorf("request %d headers = %v, want the event's ID and type", i+1, request.Header)
+		}
+		timestamp, err := strconv.ParseInt(request.Header.Get(HeaderTimestamp), 10, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := request.Header.Get(HeaderSignature), Sign("s3cret", timestamp, []byte(receiver.bodies[i])); got != want {
+			t.Errorf("request %d signature = %q, want %q", i+1, got, want)
+		}
+	}
+}
+
+func TestDispatcherGivesUp(t *testing.T) {
+	receiver := &receiver{statuses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	allowPrivateForTest(t)
+
+	store := models.NewMemoryStore(models.MemorySeed{})
+	dispatcher := New(store.Webhooks, store.Jobs, 2, time.Millisecond)
+
+	webhook := models.Webhook{ID: primitive.NewObjectID(), URL: server.URL, Events: []models.EventType{models.EventStatusChanged}}
+	if _, err := store.Webhooks.CreateWebhook(webhook); err != nil {
+		t.Fatal(err)
+	}
+	if err := dispatcher.Handle(models.Event{ID: primitive.NewObjectID(), Type: models.EventStatusChanged}); err != nil {
+		t.Fatal(err)
+	}
+
+	deliveries := waitForDeliveries(t, store.Webhooks, webhook.ID, 2)
+	last := deliveries[len(deliveries)-1]
+	if last.Attempt != 2 || last.Delivered || last.NextAttemptAt != nil {
+		t.Errorf("last attempt = %+v, want a second, failed attempt with no retry", last)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+	if len(receiver.requests) != 2 {
+		t.Errorf("receiver got %d requests, want 2", len(receiver.requests))
+	}
+}
+
+func TestClientRefusesInternalAddresses(t *testing.T) {
+	server := httptest.NewTLSServer(&receiver{})
+	defer server.Close()
+
+	dispatcher := &Dispatcher{client: newClient()}
+	tests := []string{
+		server.URL,
+		strings.Replace(server.URL, "https://", "http://", 1),
+	}
+	for _, url := range tests {
+		_, err := dispatcher.post(models.Webhook{URL: url}, delivery{Body: []byte("{}")})
+		if !errors.Is(err, ErrUnsafeTarget) {
+			t.Errorf("post to %s = %v, want ErrUnsafeTarget", url, err)
+		}
+	}
+}
@@ -5,6 +5,8 @@ import Login from "./login";
 import Navbar from "./components/Navbar";
 import LecturerHome from "./components/Lecturer/Home";
 import LecturerComplaint from "./components/Lecturer/ComplaintDetails";
+import AdvisorHome from "./components/Advisor/Home";
+import AdvisorComplaint from "./components/Advisor/ComplaintDetails";
 import HODHome from "./components/Hod/Home";
 import HODComplaint from "./components/Hod/ComplaintDetails";
 import SenateHome from "./components/Senate/Home";
@@ -26,6 +28,8 @@ function App() {
         <Route path="/new-complaint" element={<ComplaintForm />} />
         <Route path="/lecturer-dashboard" element={<LecturerHome />} />
         <Route path="/lecturer-dashboard/complaint/:id" element={<LecturerComplaint />} />
+        <Route path="/advisor-dashboard" element={<AdvisorHome />} />
+        <Route path="/advisor-dashboard/complaint/:id" element={<AdvisorComplaint />} />
         <Route path="/hod-dashboard" element={<HODHome />} />
         <Route path="/hod-dashboard/complaint/:id" element={<HODComplaint />} />
         <Route path="/senate-dashboard" element={<SenateHome />} />
@@ -0,0 +1,152 @@
+import React, { useState, useEffect, Fragment } from "react";
+import { useNavigate } from "react-router-dom";
+import '../Home.css';
+import NotificationBell from '../NotificationBell';
+import useComplaintEvents from '../useComplaintEvents';
+
+const AdvisorHome = () => {
+  const [complaints, setComplaints] = useState([]);
+  const [isLoaded, setIsLoaded] = useState(false);
+  const [error, setError] = useState(null);
+  const [currentPage, setCurrentPage] = useState(1);
+  const [complaintsPerPage, setComplaintsPerPage] = useState(10);
+  const [totalPages, setTotalPages] = useState(0);
+  const navigate = useNavigate();
+  const token = sessionStorage.getItem("token");
+  const userID = sessionStorage.getItem("userID");
+  // refetch the list whenever a complaint the user can see is created or changes
+  const [version, setVersion] = useState(0);
+  useComplaintEvents(() => setVersion((v) => v + 1));
+
+  useEffect(() => {
+    fetch(`http://localhost:4000/advisor-complaints?page=${currentPage}&per_page=${complaintsPerPage}`, {
+      headers: {
+        Authorization: token,
+      },
+    })
+      .then((response) => {
+        if (response.status !== 200) {
+          let err = new Error();
+          err.message = "Invalid response code: " + response.status;
+          throw err;
+        }
+        return response.json();
+      })
+      .then((json) => {
+        if (Array.isArray(json.complaints)) {
+        setComplaints(json.complaints);
+        setTotalPages(json.total_pages);
+      } else {
+        setComplaints([]);
+      }
+      setIsLoaded(true);
+    })
+    .catch((error) => {
+      setIsLoaded(true);
+      setError(error);
+      });
+  }, [token, userID, currentPage, complaintsPerPage, version]);
+
+  const handlePageChange = (pageNumber) => {
+    setCurrentPage(pageNumber);
+  };
+
+  const handleComplaintsPerPageChange = (evt) => {
+    setComplaintsPerPage(Number(evt.target.value));
+    setCurrentPage(1);
+  };
+
+
+  const renderPaginationButtons = () => {
+    const pageNumbers = [];
+    for (let i = 1; i <= totalPages; i++) {
+      pageNumbers.push(i);
+    }
+
+    return (
+      <div className="flex justify-center mt-4">
+        {pageNumbers.map((number) => (
+          <button
+            key={number}
+            className={`mx-1 px-3 py-1 rounded ${currentPage === number ? "bg-blue-500 text-white" : "bg-gray-200"}`}
+            onClick={() => handlePageChange(number)}
+          >
+            {number}
+          </button>
+        ))}
+      </div>
+    );
+  };
+
+  if (error) {
+    return <div className="text-red-500 text-center mt-4">Error: {error.message}</div>;
+  } else if (!isLoaded) {
+    return <p className="text-center mt-4">Loading...</p>;
+  } else {
+    // the server already returns just the current page
+    const currentComplaints = complaints;
+
+    return (
+      <Fragment>
+      <div className="container mx-auto px-4 py-8">
+        <div className="flex justify-end mb-4">
+          <NotificationBell />
+        </div>
+        {complaints.length > 0 ? (
+          <div>
+              <table className="table-auto w-full">
+                <thead>
+                  <tr>
+                    <th className="border px-4 py-2">Course Concerned</th>
+                    <th className="border px-4 py-2">Student Involved</th>
+                    <th className="border px-4 py-2">Assigned Lecturer</th>
+                    <th className="border px-4 py-2">Proof</th>
+                  </tr>
+                </thead>
+                <tbody>
+                  {currentComplaints.map((complaint) => (
+                    <tr
+                      key={complaint._id}
+                      onClick={(e) => {
+                        e.preventDefault();
+                        navigate(`complaint/${complaint._id}`);
+                      }}
+                      style={{ cursor: "pointer" }}
+                    >
+                      <td className="border px-4 py-2">{complaint.course_concerned}</td>
+                      <td className="border px-4 py-2">{complaint.requesting_student}</td>
+                      <td className="border px-4 py-2">{complaint.responding_lecturer}</td>
+                      <td className="border px-4 py-2">
+                        {complaint.thumbnail_url && <img src={`http://localhost:4000${complaint.thumbnail_url}`} alt="proof" className="h-12 w-auto rounded" loading="lazy" />}
+                      </td>
+                    </tr>
+                  ))}
+                </tbody>
+              </table>
+
+              <div className="mt-8 flex justify-between items-center">
+                {renderPaginationButtons()}
+                <select
+                  className="ml-4 px-2 py-1 rounded border"
+                  value={complaintsPerPage}
+                  onChange={handleComplaintsPerPageChange}
+                >
+                  <option value={10}>10</option>
+                  <option value={50}>50</option>
+                  <option value={100}>100</option>
+                </select>
+              </div>
+            </div>
+        ) : (
+          <div className="flex justify-center">
+            <h2>You have no re-evaluation requests at the moment</h2>
+          </div>
+        )}
+
+        </div>
+      </Fragment>
+    );
+  }
+};
+
+export default AdvisorHome;
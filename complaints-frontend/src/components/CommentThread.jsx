@@ -0,0 +1,159 @@
+import React, { useState, useEffect, useCallback } from "react";
+
+// roles that can read a staff-only comment
+const STAFF_ROLES = "L,H,B";
+
+const roleNames = {
+  S: "Student",
+  L: "Lecturer",
+  H: "HOD",
+  B: "Senate",
+};
+
+const CommentThread = ({ complaintID }) => {
+  const [comments, setComments] = useState([]);
+  const [body, setBody] = useState("");
+  const [files, setFiles] = useState([]);
+  const [staffOnly, setStaffOnly] = useState(false);
+  const [isPosting, setIsPosting] = useState(false);
+  const [errorMessage, setErrorMessage] = useState("");
+  const token = sessionStorage.getItem("token");
+  const role = sessionStorage.getItem("role");
+
+  const fetchComments = useCallback(() => {
+    fetch(`http://localhost:4000/complaint/${complaintID}/comments`, {
+      headers: {
+        Authorization: token,
+      },
+    })
+      .then((response) => (response.status === 200 ? response.json() : null))
+      .then((json) => {
+        if (json) {
+          setComments(json.comments);
+        }
+      })
+      .catch(() => {});
+  }, [token, complaintID]);
+
+  useEffect(() => {
+    if (complaintID) {
+      fetchComments();
+    }
+  }, [complaintID, fetchComments]);
+
+  const handleFileChange = (e) => {
+    const selectedFiles = Array.from(e.target.files);
+    const totalSize = selectedFiles.reduce((total, f) => total + f.size, 0);
+    if (totalSize > 10 * 1024 * 1024) { // 10 MB limit
+      setErrorMessage("Files exceed the 10 MB limit.");
+      setFiles([]);
+    } else {
+      setErrorMessage("");
+      setFiles(selectedFiles);
+    }
+  };
+
+  const handleSubmit = (e) => {
+    e.preventDefault();
+    if (body.trim() === "") {
+      setErrorMessage("Write a comment first.");
+      return;
+    }
+
+    const formData = new FormData();
+    formData.append("body", body);
+    if (staffOnly) {
+      formData.append("visible_to", STAFF_ROLES);
+    }
+    files.forEach((file) => formData.append("file", file));
+
+    setIsPosting(true);
+    fetch(`http://localhost:4000/complaint/${complaintID}/comments`, {
+      method: "POST",
+      headers: {
+        Authorization: token,
+      },
+      body: formData,
+    })
+      .then((response) => response.json().then((json) => ({ ok: response.ok, json })))
+      .then(({ ok, json }) => {
+        if (!ok) {
+          throw new Error(json.error ? json.error.message : "Unable to post comment");
+        }
+        setBody("");
+        setFiles([]);
+        setStaffOnly(false);
+        setErrorMessage("");
+        fetchComments();
+      })
+      .catch((error) => setErrorMessage(error.message))
+      .finally(() => setIsPosting(false));
+  };
+
+  return (
+    <div className="bg-gray-100 p-4 rounded-lg mb-4">
+      <h2 className="text-xl font-semibold mb-2">Discussion</h2>
+      {comments.length === 0 ? (
+        <p className="text-gray-500 mb-4">No comments yet.</p>
+      ) : (
+        <ul className="mb-4">
+          {comments.map((comment) => (
+            <li key={comment._id} className="bg-white p-3 rounded mb-2">
+              <p className="text-sm text-gray-600">
+                {comment.author.user_id} ({roleNames[comment.author.role] || comment.author.role}) &middot;{" "}
+                {new Date(comment.created_at).toLocaleString()}
+                {comment.visible_to.length < Object.keys(roleNames).length && (
+                  <span className="ml-2 text-xs text-yellow-700">
+                    visible to {comment.visible_to.map((r) => roleNames[r] || r).join(", ")}
+                  </span>
+                )}
+              </p>
+              <p className="whitespace-pre-wrap">{comment.body}</p>
+              {comment.attachments && comment.attachments.length > 0 && (
+                <ul className="list-disc ml-6 mt-2">
+                  {comment.attachments.map((attachment) => (
+                    <li key={attachment._id}>
+                      <a href={`http://localhost:4000${attachment.url}`} target="_blank" rel="noreferrer" className="text-blue-500 underline">{attachment.filename}</a>
+                    </li>
+                  ))}
+                </ul>
+              )}
+            </li>
+          ))}
+        </ul>
+      )}
+
+      <form onSubmit={handleSubmit}>
+        <textarea
+          className="w-full p-2 border rounded mb-2"
+          rows={3}
+          value={body}
+          onChange={(e) => setBody(e.target.value)}
+          placeholder="Ask a question or add a note"
+        />
+        <input type="file" multiple onChange={handleFileChange} className="mb-2" />
+        {role !== "S" && (
+          <label className="block mb-2">
+            <input
+              type="checkbox"
+              className="mr-2"
+              checked={staffOnly}
+              onChange={(e) => setStaffOnly(e.target.checked)}
+            />
+            Staff only (hidden from the student)
+          </label>
+        )}
+        <button
+          type="submit"
+          disabled={isPosting}
+          className="bg-blue-500 hover:bg-blue-700 text-white font-bold py-2 px-4 rounded"
+        >
+          {isPosting ? "Posting..." : "Post comment"}
+        </button>
+      </form>
+      {errorMessage && <p className="text-red-500 mt-2">{errorMessage}</p>}
+    </div>
+  );
+};
+
+export default CommentThread;
@@ -1,6 +1,7 @@
 import React, { Fragment, useEffect, useState } from 'react';
 import { useParams, useNavigate } from 'react-router-dom';
 import axios from 'axios';
+import CommentThread from '../CommentThread';
 
 const Complaint = () => {
   const { id } = useParams();
@@ -8,6 +9,7 @@ const Complaint = () => {
   const navigate = useNavigate();
   const [isAccepting, setIsAccepting] = useState(false);
   const [isDeclining, setIsDeclining] = useState(false);
+  const [declineReason, setDeclineReason] = useState("");
 
   const [complaint, setComplaint] = useState({
     id: id,
@@ -15,6 +17,7 @@ const Complaint = () => {
     details: "",
     student_proof: "",
     lecturer_proof: "",
+    attachments: [],
     reason: "",
   });
   const [isLoaded, setIsLoaded] = useState(false);
@@ -39,8 +42,9 @@ const Complaint = () => {
         setComplaint({
           matricNo: json.complaint.requesting_student,
           details: json.complaint.request_details,
-          student_proof: `http://localhost:4000/${json.complaint.student_proof}`,
-          lecturer_proof: `http://localhost:4000/${json.complaint.lecturer_proof}`,
+          student_proof: `http://localhost:4000${json.complaint.student_proof_url}`,
+          lecturer_proof: `http://localhost:4000${json.complaint.lecturer_proof_url}`,
+          attachments: json.complaint.attachments || [],
           reason: json.complaint.reason,
         });
         setIsLoaded(true);
@@ -81,9 +85,13 @@ const Complaint = () => {
 
   const handleDecline = (e) => {
     e.preventDefault();
+    if (declineReason.trim() === "") {
+      setErrorMessage("Please give a reason for declining this complaint.");
+      return;
+    }
     setIsDeclining(true);
 
-    axios.put(`http://localhost:4000/decline/${id}`, {}, {
+    axios.put(`http://localhost:4000/decline/${id}`, { reason: declineReason }, {
       headers: {
         Authorization: token,
       }
@@ -125,7 +133,23 @@ const Complaint = () => {
             <p><span className="font-semibold">Status</span>: {complaint.status}</p>
             <p className="mb-4">{complaint.reason}</p>
             {complaint.status !== "Pending" ? (<img src={complaint.lecturer_proof} className="max-w-full h-auto rounded-lg" alt="lecturer proof"/>): (<div></div>)}
+            {complaint.attachments.length > 0 && (
+              <ul className="list-disc ml-6 mt-4">
+                {complaint.attachments.map((attachment) => (
+                  <li key={attachment._id}>
+                    <a href={`http://localhost:4000${attachment.url}`} target="_blank" rel="noreferrer" className="text-blue-500 underline">{attachment.filename}</a>
+                  </li>
+                ))}
+              </ul>
+            )}
           </div>
+          <label className="block mb-2" htmlFor='decline_reason'>Reason for declining</label>
+          <textarea
+            id="decline_reason"
+            className="block w-full border border-gray-300 rounded px-3 py-2 mb-4"
+            value={declineReason}
+            onChange={(e) => setDeclineReason(e.target.value)}
+          />
           <div className="flex space-x-4">
             <button className="bg-green-500 text-white py-2 px-4 rounded hover:bg-green-600" onClick={handleAccept}>
               {isAccepting ? (
@@ -179,6 +203,9 @@ const Complaint = () => {
             )}</button>
           </div>
           {errorMessage && <p className="text-red-500 mt-4">{errorMessage}</p>}
+          <div className="mt-6">
+            <CommentThread complaintID={id} />
+          </div>
         </div>
       </Fragment>
     );
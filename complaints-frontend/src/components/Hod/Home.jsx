@@ -1,6 +1,8 @@
 import React, { useState, useEffect, Fragment } from "react";
 import { useNavigate } from "react-router-dom";
 import '../Home.css';
+import NotificationBell from '../NotificationBell';
+import useComplaintEvents from '../useComplaintEvents';
 
 const HODHome = () => {
   const [complaints, setComplaints] = useState([]);
@@ -8,12 +10,16 @@ const HODHome = () => {
   const [error, setError] = useState(null);
   const [currentPage, setCurrentPage] = useState(1);
   const [complaintsPerPage, setComplaintsPerPage] = useState(10);
+  const [totalPages, setTotalPages] = useState(0);
   const navigate = useNavigate();
   const token = sessionStorage.getItem("token");
   const userID = sessionStorage.getItem("userID");
+  // refetch the list whenever a complaint the user can see is created or changes
+  const [version, setVersion] = useState(0);
+  useComplaintEvents(() => setVersion((v) => v + 1));
 
   useEffect(() => {
-    fetch(`http://localhost:4000/hod-complaints`, {
+    fetch(`http://localhost:4000/hod-complaints?page=${currentPage}&per_page=${complaintsPerPage}`, {
       headers: {
         Authorization: token,
       },
@@ -28,11 +34,8 @@ const HODHome = () => {
       })
       .then((json) => {
         if (Array.isArray(json.complaints)) {
-        // Filter out complaints with the status "pending"
-        const filteredComplaints = json.complaints.filter(
-          (complaint) => complaint.status === "Approved By Lecturer"
-        );
-        setComplaints(filteredComplaints);
+        setComplaints(json.complaints);
+        setTotalPages(json.total_pages);
       } else {
         setComplaints([]);
       }
@@ -42,9 +45,7 @@ const HODHome = () => {
       setIsLoaded(true);
       setError(error);
       });
-  }, [token, userID]);
-
-  const totalPages = complaints ? Math.ceil(complaints.length / complaintsPerPage) : 0;
+  }, [token, userID, currentPage, complaintsPerPage, version]);
 
   const handlePageChange = (pageNumber) => {
     setCurrentPage(pageNumber);
@@ -82,13 +83,15 @@ const HODHome = () => {
   } else if (!isLoaded) {
     return <p className="text-center mt-4">Loading...</p>;
   } else {
-    const indexOfLastComplaint = currentPage * complaintsPerPage;
-    const indexOfFirstComplaint = indexOfLastComplaint - complaintsPerPage;
-    const currentComplaints = complaints ? complaints.slice(indexOfFirstComplaint, indexOfLastComplaint) : [];
+    // the server already returns just the current page
+    const currentComplaints = complaints;
 
     return (
       <Fragment>
       <div className="container mx-auto px-4 py-8">
+        <div className="flex justify-end mb-4">
+          <NotificationBell />
+        </div>
         {complaints.length > 0 ? (
           <div>
               <table className="table-auto w-full">
@@ -97,6 +100,8 @@ const HODHome = () => {
                     <th className="border px-4 py-2">Course Concerned</th>
                     <th className="border px-4 py-2">Student Involved</th>
                     <th className="border px-4 py-2">Assigned Lecturer</th>
+                    <th className="border px-4 py-2">Due</th>
+                    <th className="border px-4 py-2">Proof</th>
                   </tr>
                 </thead>
                 <tbody>
@@ -112,6 +117,17 @@ const HODHome = () => {
                       <td className="border px-4 py-2">{complaint.course_concerned}</td>
                       <td className="border px-4 py-2">{complaint.requesting_student}</td>
                       <td className="border px-4 py-2">{complaint.responding_lecturer}</td>
+                      <td className="border px-4 py-2">
+                        {complaint.due_at && new Date(complaint.due_at).toLocaleDateString()}
+                        {complaint.escalated && (
+                          <span className="ml-2 px-2 py-1 rounded bg-red-100 text-red-700 text-xs">
+                            Escalated: {complaint.status}
+                          </span>
+                        )}
+                      </td>
+                      <td className="border px-4 py-2">
+                        {complaint.thumbnail_url && <img src={`http://localhost:4000${complaint.thumbnail_url}`} alt="proof" className="h-12 w-auto rounded" loading="lazy" />}
+                      </td>
                     </tr>
                   ))}
                 </tbody>
@@ -1,13 +1,14 @@
 import React, { Fragment, useEffect, useState } from 'react';
 import { useParams, useNavigate } from 'react-router-dom';
 import axios from 'axios';
+import CommentThread from '../CommentThread';
 
 const Complaint = () => {
   const { id } = useParams();
   const token = sessionStorage.getItem("token");
   const navigate = useNavigate();
   const [reason, setReason] = useState("");
-  const [file, setFile] = useState(null);
+  const [files, setFiles] = useState([]);
   const [isAccepting, setIsAccepting] = useState(false);
   const [isDeclining, setIsDeclining] = useState(false);
 
@@ -16,6 +17,7 @@ const Complaint = () => {
     matricNo: "",
     details: "",
     student_proof: "",
+    attachments: [],
   });
   const [isLoaded, setIsLoaded] = useState(false);
   const [error, setError] = useState(null);
@@ -39,7 +41,8 @@ const Complaint = () => {
         setComplaint({
           matricNo: json.complaint.requesting_student,
           details: json.complaint.request_details,
-          student_proof: `http://localhost:4000/${json.complaint.student_proof}`, // Update the file path
+          student_proof: `http://localhost:4000${json.complaint.student_proof_url}`, // Update the file path
+          attachments: json.complaint.attachments || [],
         });
         setIsLoaded(true);
       })
@@ -50,13 +53,14 @@ const Complaint = () => {
   }, [token, id]);
 
   const handleFileChange = (e) => {
-  const selectedFile = e.target.files[0];
-  if (selectedFile && selectedFile.size > 10 * 1024 * 1024) { // 10 MB limit
-    setErrorMessage("File size exceeds the 10 MB limit.");
-    setFile(null);
+  const selectedFiles = Array.from(e.target.files);
+  const totalSize = selectedFiles.reduce((total, f) => total + f.size, 0);
+  if (totalSize > 10 * 1024 * 1024) { // 10 MB limit
+    setErrorMessage("Files exceed the 10 MB limit.");
+    setFiles([]);
   } else {
     setErrorMessage("");
-    setFile(selectedFile);
+    setFiles(selectedFiles);
   }
 };
 
@@ -67,7 +71,7 @@ const Complaint = () => {
 
     const formData = new FormData();
     formData.append("reason", reason);
-    formData.append("file", file);
+    files.forEach((f) => formData.append("file", f));
     console.log("FormData before sending (Accept):", Object.fromEntries(formData));
 
 
@@ -101,7 +105,7 @@ const Complaint = () => {
     setIsDeclining(true);
     const formData = new FormData();
     formData.append("reason", reason);
-    formData.append("file", file);
+    files.forEach((f) => formData.append("file", f));
     console.log("FormData before sending (Decline):", Object.fromEntries(formData));
 
 
@@ -143,6 +147,15 @@ const Complaint = () => {
             <h2 className="text-xl font-semibold mb-2">Details</h2>
             <p className="mb-4">{complaint.details}</p>
             {complaint.student_proof && <img src={complaint.student_proof} alt='complaint' className="max-w-full h-auto rounded-lg" />}
+            {complaint.attachments.length > 0 && (
+              <ul className="list-disc ml-6 mt-4">
+                {complaint.attachments.map((attachment) => (
+                  <li key={attachment._id}>
+                    <a href={`http://localhost:4000${attachment.url}`} target="_blank" rel="noreferrer" className="text-blue-500 underline">{attachment.filename}</a>
+                  </li>
+                ))}
+              </ul>
+            )}
           </div>
 
           <form
@@ -158,6 +171,8 @@ const Complaint = () => {
           <label htmlFor="file" className="block mb-2">Upload File</label>
           <input
             type="file"
+            multiple
+            accept=".pdf,.png,.jpg,.jpeg,.heic,application/pdf,image/png,image/jpeg,image/heic"
             id="file"
             onChange={handleFileChange}
             className="block w-full border border-gray-300 rounded px-3 py-2 mb-4"
@@ -218,6 +233,9 @@ const Complaint = () => {
             </button>
           </div>
           {errorMessage && <p className="text-red-500 mt-4">{errorMessage}</p>}
+          <div className="mt-6">
+            <CommentThread complaintID={id} />
+          </div>
         </div>
       </Fragment>
     );
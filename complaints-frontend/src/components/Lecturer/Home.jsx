@@ -1,6 +1,8 @@
 import React, { useState, useEffect, Fragment } from "react";
 import { useNavigate } from "react-router-dom";
 import '../Home.css';
+import NotificationBell from '../NotificationBell';
+import useComplaintEvents from '../useComplaintEvents';
 
 const LecturerHome = () => {
   const [complaints, setComplaints] = useState([]);
@@ -8,11 +10,15 @@ const LecturerHome = () => {
   const [error, setError] = useState(null);
   const [currentPage, setCurrentPage] = useState(1);
   const [complaintsPerPage, setComplaintsPerPage] = useState(10);
+  const [totalPages, setTotalPages] = useState(0);
   const [courses, setCourses] = useState([]);
   const [selectedCourse, setSelectedCourse] = useState("");
   const navigate = useNavigate();
   const token = sessionStorage.getItem("token");
   const userID = sessionStorage.getItem("userID");
+  // refetch the list whenever a complaint the user can see is created or changes
+  const [version, setVersion] = useState(0);
+  useComplaintEvents(() => setVersion((v) => v + 1));
 
   useEffect(() => {
     console.log("Fetching courses...");
@@ -43,7 +49,7 @@ const LecturerHome = () => {
   useEffect(() => {
     if (selectedCourse) {
       console.log("Fetching complaints for course:", selectedCourse);
-      fetch(`http://localhost:4000/lecturer-complaints/${userID}?course=${selectedCourse}`, {
+      fetch(`http://localhost:4000/lecturer-complaints/${userID}?course=${selectedCourse}&status=Pending&page=${currentPage}&per_page=${complaintsPerPage}`, {
         headers: {
           Authorization: token,
         }
@@ -60,10 +66,8 @@ const LecturerHome = () => {
         .then((json) => {
           console.log("Complaints received:", json.complaints);
           if (json.complaints) {
-            const filteredComplaints = json.complaints.filter(
-              (complaint) => complaint.status === "Pending"
-            );
-            setComplaints(filteredComplaints);
+            setComplaints(json.complaints);
+            setTotalPages(json.total_pages);
           } else {
             setComplaints([]);
           }
@@ -77,9 +81,7 @@ const LecturerHome = () => {
     } else {
       setIsLoaded(true);
     }
-  }, [selectedCourse, token, userID]);
-
-  const totalPages = complaints ? Math.ceil(complaints.length / complaintsPerPage) : 0;
+  }, [selectedCourse, token, userID, currentPage, complaintsPerPage, version]);
 
   const handlePageChange = (pageNumber) => {
     setCurrentPage(pageNumber);
@@ -116,13 +118,15 @@ const LecturerHome = () => {
   } else if (!isLoaded) {
     return <p className="text-center mt-4">Loading...</p>;
   } else {
-    const indexOfLastComplaint = currentPage * complaintsPerPage;
-    const indexOfFirstComplaint = indexOfLastComplaint - complaintsPerPage;
-    const currentComplaints = complaints.slice(indexOfFirstComplaint, indexOfLastComplaint);
+    // the server already returns just the current page
+    const currentComplaints = complaints;
 
     return (
       <Fragment>
         <div className="container mx-auto px-4 py-8">
+          <div className="flex justify-end mb-4">
+            <NotificationBell />
+          </div>
           <div className="mb-4">
             <label htmlFor="course_select" className="block mb-2">Select Course</label>
             <select
@@ -144,6 +148,8 @@ const LecturerHome = () => {
                   <tr>
                     <th className="border px-4 py-2">Course Concerned</th>
                     <th className="border px-4 py-2">Requesting Student</th>
+                    <th className="border px-4 py-2">Due</th>
+                    <th className="border px-4 py-2">Proof</th>
                   </tr>
                 </thead>
                 <tbody>
@@ -156,6 +162,12 @@ const LecturerHome = () => {
                       style={{ cursor: "pointer" }}>
                       <td className="border px-4 py-2">{complaint.course_concerned}</td>
                       <td className="border px-4 py-2">{complaint.requesting_student}</td>
+                      <td className="border px-4 py-2">
+                        {complaint.due_at && new Date(complaint.due_at).toLocaleDateString()}
+                      </td>
+                      <td className="border px-4 py-2">
+                        {complaint.thumbnail_url && <img src={`http://localhost:4000${complaint.thumbnail_url}`} alt="proof" className="h-12 w-auto rounded" loading="lazy" />}
+                      </td>
                     </tr>
                   ))}
                 </tbody>
@@ -0,0 +1,107 @@
+import React, { useState, useEffect, useCallback, Fragment } from "react";
+import { useNavigate } from "react-router-dom";
+
+// how often the unread count is refreshed, in milliseconds
+const POLL_INTERVAL = 30000;
+
+const NotificationBell = () => {
+  const [unread, setUnread] = useState(0);
+  const [notifications, setNotifications] = useState([]);
+  const [isOpen, setIsOpen] = useState(false);
+  const navigate = useNavigate();
+  const token = sessionStorage.getItem("token");
+
+  const fetchUnread = useCallback(() => {
+    fetch(`http://localhost:4000/notifications/unread-count`, {
+      headers: {
+        Authorization: token,
+      },
+    })
+      .then((response) => (response.status === 200 ? response.json() : null))
+      .then((json) => {
+        if (json) {
+          setUnread(json.unread);
+        }
+      })
+      .catch(() => {});
+  }, [token]);
+
+  useEffect(() => {
+    fetchUnread();
+    const timer = setInterval(fetchUnread, POLL_INTERVAL);
+    return () => clearInterval(timer);
+  }, [fetchUnread]);
+
+  const markRead = (ids) =>
+    fetch(`http://localhost:4000/notifications/read`, {
+      method: "POST",
+      headers: {
+        Authorization: token,
+      },
+      body: JSON.stringify({ ids }),
+    }).then(fetchUnread);
+
+  const handleToggle = () => {
+    if (isOpen) {
+      setIsOpen(false);
+      return;
+    }
+    fetch(`http://localhost:4000/notifications`, {
+      headers: {
+        Authorization: token,
+      },
+    })
+      .then((response) => (response.status === 200 ? response.json() : { notifications: [] }))
+      .then((json) => {
+        setNotifications(json.notifications || []);
+        setIsOpen(true);
+      })
+      .catch(() => {});
+  };
+
+  const handleOpen = (notification) => {
+    setIsOpen(false);
+    markRead([notification._id]);
+    navigate(notification.link);
+  };
+
+  return (
+    <div className="relative inline-block">
+      <button className="relative bg-gray-200 px-3 py-1 rounded" onClick={handleToggle}>
+        Notifications
+        {unread > 0 && (
+          <span className="ml-2 bg-red-500 text-white text-xs font-bold px-2 py-0.5 rounded-full">{unread}</span>
+        )}
+      </button>
+      {isOpen && (
+        <div className="absolute right-0 mt-2 w-80 bg-white border rounded shadow-lg z-10">
+          {notifications.length > 0 ? (
+            <Fragment>
+              <ul className="max-h-96 overflow-y-auto">
+                {notifications.map((notification) => (
+                  <li
+                    key={notification._id}
+                    onClick={() => handleOpen(notification)}
+                    className={`px-4 py-2 border-b cursor-pointer ${notification.read ? "text-gray-500" : "font-semibold"}`}
+                  >
+                    <p>{notification.title}</p>
+                    <p className="text-xs text-gray-400">{new Date(notification.created_at).toLocaleString()}</p>
+                  </li>
+                ))}
+              </ul>
+              {unread > 0 && (
+                <button className="w-full text-blue-500 py-2" onClick={() => markRead([]).then(() => setIsOpen(false))}>
+                  Mark all as read
+                </button>
+              )}
+            </Fragment>
+          ) : (
+            <p className="px-4 py-2 text-gray-500">No notifications</p>
+          )}
+        </div>
+      )}
+    </div>
+  );
+};
+
+export default NotificationBell;
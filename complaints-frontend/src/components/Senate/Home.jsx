@@ -1,6 +1,8 @@
 import React, { useState, useEffect, Fragment } from "react";
 import { useNavigate } from "react-router-dom";
 import '../Home.css';
+import NotificationBell from '../NotificationBell';
+import useComplaintEvents from '../useComplaintEvents';
 
 const LecturerHome = () => {
   const [complaints, setComplaints] = useState([]);
@@ -8,11 +10,15 @@ const LecturerHome = () => {
   const [error, setError] = useState(null);
   const [currentPage, setCurrentPage] = useState(1);
   const [complaintsPerPage, setComplaintsPerPage] = useState(10);
+  const [totalPages, setTotalPages] = useState(0);
   const navigate = useNavigate()
   const token = sessionStorage.getItem("token");
+  // refetch the list whenever a complaint the user can see is created or changes
+  const [version, setVersion] = useState(0);
+  useComplaintEvents(() => setVersion((v) => v + 1));
 
   useEffect(() => {
-    fetch(`http://localhost:4000/senate-complaints`, {
+    fetch(`http://localhost:4000/senate-complaints?page=${currentPage}&per_page=${complaintsPerPage}`, {
       headers: {
         Authorization: token,
       },
@@ -27,11 +33,8 @@ const LecturerHome = () => {
       })
       .then((json) => {
         if (json.complaints) {
-          // Filter out complaints with the status "Approved By HOD"
-          const filteredComplaints = json.complaints.filter(
-            (complaint) => complaint.status === "Approved By HOD"
-          );
-          setComplaints(filteredComplaints);
+          setComplaints(json.complaints);
+          setTotalPages(json.total_pages);
         } else {
           setComplaints([]);
         }
@@ -41,9 +44,7 @@ const LecturerHome = () => {
         setIsLoaded(true);
         setError(error);
       });
-  }, [token]);
-
-  const totalPages = complaints ? Math.ceil(complaints.length / complaintsPerPage) : 0;
+  }, [token, currentPage, complaintsPerPage, version]);
 
   const handlePageChange = (pageNumber) => {
     setCurrentPage(pageNumber);
@@ -80,13 +81,15 @@ const LecturerHome = () => {
   } else if (!isLoaded) {
     return <p className="text-center mt-4">Loading...</p>;
   } else {
-    const indexOfLastComplaint = currentPage * complaintsPerPage;
-    const indexOfFirstComplaint = indexOfLastComplaint - complaintsPerPage;
-    const currentComplaints = complaints.slice(indexOfFirstComplaint, indexOfLastComplaint);
+    // the server already returns just the current page
+    const currentComplaints = complaints;
 
     return (
       <Fragment>
         <div className="container mx-auto px-4 py-8">
+          <div className="flex justify-end mb-4">
+            <NotificationBell />
+          </div>
           {complaints.length > 0 ? (
             <div>
               <table className="table-auto w-full">
@@ -95,6 +98,7 @@ const LecturerHome = () => {
                     <th className="border px-4 py-2">Course Concerned</th>
                     <th className="border px-4 py-2">Student Involved</th>
                     <th className="border px-4 py-2">Assigned Lecturer</th>
+                    <th className="border px-4 py-2">Proof</th>
                   </tr>
                 </thead>
                 <tbody>
@@ -108,6 +112,9 @@ const LecturerHome = () => {
                       <td className="border px-4 py-2">{complaint.course_concerned}</td>
                       <td className="border px-4 py-2">{complaint.requesting_student}</td>
                       <td className="border px-4 py-2">{complaint.responding_lecturer}</td>
+                      <td className="border px-4 py-2">
+                        {complaint.thumbnail_url && <img src={`http://localhost:4000${complaint.thumbnail_url}`} alt="proof" className="h-12 w-auto rounded" loading="lazy" />}
+                      </td>
                     </tr>
                   ))}
                 </tbody>
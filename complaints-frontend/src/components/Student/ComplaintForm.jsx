@@ -9,7 +9,7 @@ export default function ComplaintForm() {
   const [courseConcerned, setCourseConcerned] = useState("");
   const [requestDetails, setRequestDetails] = useState("");
   const [testScore, setTestScore] = useState("");
-  const [file, setFile] = useState(null);
+  const [files, setFiles] = useState([]);
   const [successMessage, setSuccessMessage] = useState("");
   const [errorMessage, setErrorMessage] = useState("");
   const [isSubmitting, setIsSubmitting] = useState(false);
@@ -56,9 +56,7 @@ export default function ComplaintForm() {
     formData.append("course_concerned", courseConcerned);
     formData.append("request_details", requestDetails);
     formData.append("test_score", testScoreInt);
-    if (file) {
-      formData.append("file", file);
-    }
+    files.forEach((f) => formData.append("file", f));
 
     axios.post(`http://localhost:4000/complaint`, formData, {
       headers: {
@@ -72,7 +70,7 @@ export default function ComplaintForm() {
       setCourseConcerned("");
       setTestScore("");
       setRequestDetails("");
-      setFile(null);
+      setFiles([]);
       setIsSubmitting(false);  // Stop the spinner
       navigate("/student-dashboard")
     })
@@ -132,11 +130,13 @@ export default function ComplaintForm() {
             className="block w-full border border-gray-300 rounded px-3 py-2 mb-4"
           />
 
-          <label htmlFor="file" className="block mb-2">Upload File</label>
+          <label htmlFor="file" className="block mb-2">Upload Files</label>
           <input
             type="file"
+            multiple
+            accept=".pdf,.png,.jpg,.jpeg,.heic,application/pdf,image/png,image/jpeg,image/heic"
             id="file"
-            onChange={(e) => setFile(e.target.files[0])}
+            onChange={(e) => setFiles(Array.from(e.target.files))}
             className="block w-full border border-gray-300 rounded px-3 py-2 mb-4"
           />
 
@@ -1,6 +1,8 @@
 import React, { useState, useEffect, Fragment } from "react";
 import { useParams, useNavigate } from "react-router-dom";
 import '../Home.css';
+import NotificationBell from '../NotificationBell';
+import CommentThread from '../CommentThread';
 
 const StudentHome = () => {
   const { id } = useParams();
@@ -10,6 +12,7 @@ const StudentHome = () => {
     details: null,
     student_proof: null,
     lecturer_proof: null,
+    attachments: [],
     reason: null,
     status: null,
   });
@@ -64,12 +67,15 @@ const StudentHome = () => {
         console.log(json.complaint)
         if (json.complaint !== null) {
         setComplaint({
+          id: json.complaint._id,
           matricNo: json.complaint.requesting_student,
           details: json.complaint.request_details,
-          student_proof: `http://localhost:4000/${json.complaint.student_proof}`,
-          lecturer_proof: `http://localhost:4000/${json.complaint.lecturer_proof}`,
+          student_proof: `http://localhost:4000${json.complaint.student_proof_url}`,
+          lecturer_proof: json.complaint.lecturer_proof ? `http://localhost:4000${json.complaint.lecturer_proof_url}` : "",
+          attachments: json.complaint.attachments || [],
           reason: json.complaint.reason,
           status: json.complaint.status,
+          decline: json.complaint.decline,
         });
       } else {
         setComplaint(null)
@@ -98,6 +104,9 @@ const StudentHome = () => {
     return (
       <Fragment>
       <div className="container mx-auto px-4 py-8">
+        <div className="flex justify-end mb-4">
+          <NotificationBell />
+        </div>
         <div className="flex justify-between items-center mb-8">
           <h1 className="text-2xl font-bold">My Complaints</h1>
         </div>
@@ -144,9 +153,23 @@ const StudentHome = () => {
                   <br />
                   <h2 className="text-xl font-semibold mb-2">Approval Details</h2>
                   <p><span className="font-semibold">Status</span>: {complaint.status}</p>
-                  <p className="mb-4"><span className="font-semibold">Reason</span>: {complaint.reason}</p>
-                  {complaint.status !== "Pending" ? (<img src={complaint.lecturer_proof} className="max-w-full h-auto rounded-lg" alt="lecturer proof"/>): (<div></div>)}
+                  {complaint.decline ? (
+                    <p className="mb-4"><span className="font-semibold">Declined at {complaint.decline.stage}</span>: {complaint.decline.reason}</p>
+                  ) : (
+                    <p className="mb-4"><span className="font-semibold">Reason</span>: {complaint.reason}</p>
+                  )}
+                  {complaint.status !== "Pending" && complaint.lecturer_proof ? (<img src={complaint.lecturer_proof} className="max-w-full h-auto rounded-lg" alt="lecturer proof"/>): (<div></div>)}
+                  {complaint.attachments.length > 0 && (
+                    <ul className="list-disc ml-6 mt-4">
+                      {complaint.attachments.map((attachment) => (
+                        <li key={attachment._id}>
+                          <a href={`http://localhost:4000${attachment.url}`} target="_blank" rel="noreferrer" className="text-blue-500 underline">{attachment.filename}</a>
+                        </li>
+                      ))}
+                    </ul>
+                  )}
                 </div>
+                <CommentThread complaintID={complaint.id} />
               </div>
             </div>
           ) : (
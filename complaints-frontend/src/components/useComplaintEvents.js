@@ -0,0 +1,76 @@
+import { useEffect, useRef } from "react";
+
+// how long to wait before reconnecting a dropped stream, in milliseconds
+const RECONNECT_DELAY = 5000;
+
+// useComplaintEvents calls onEvent with every complaint event the server streams to the
+// signed-in user. The stream is read with fetch rather than EventSource so the token can be
+// sent in the Authorization header.
+const useComplaintEvents = (onEvent) => {
+  const handler = useRef(onEvent);
+  handler.current = onEvent;
+  const token = sessionStorage.getItem("token");
+
+  useEffect(() => {
+    const controller = new AbortController();
+    let timer = null;
+
+    const dispatch = (block) => {
+      let type = "message";
+      const data = [];
+      block.split("\n").forEach((line) => {
+        if (line.startsWith("event:")) {
+          type = line.slice(6).trim();
+        } else if (line.startsWith("data:")) {
+          data.push(line.slice(5).trim());
+        }
+      });
+      if (data.length > 0) {
+        handler.current({ type, ...JSON.parse(data.join("\n")) });
+      }
+    };
+
+    const connect = () => {
+      fetch(`http://localhost:4000/events`, {
+        headers: {
+          Authorization: token,
+        },
+        signal: controller.signal,
+      })
+        .then(async (response) => {
+          if (response.status !== 200) {
+            throw new Error("Invalid response code: " + response.status);
+          }
+          const reader = response.body.getReader();
+          const decoder = new TextDecoder();
+          let buffer = "";
+          for (;;) {
+            const { done, value } = await reader.read();
+            if (done) {
+              break;
+            }
+            buffer += decoder.decode(value, { stream: true });
+            let end;
+            while ((end = buffer.indexOf("\n\n")) >= 0) {
+              dispatch(buffer.slice(0, end));
+              buffer = buffer.slice(end + 2);
+            }
+          }
+          throw new Error("stream closed");
+        })
+        .catch(() => {
+          if (!controller.signal.aborted) {
+            timer = setTimeout(connect, RECONNECT_DELAY);
+          }
+        });
+    };
+
+    connect();
+    return () => {
+      controller.abort();
+      clearTimeout(timer);
+    };
+  }, [token]);
+};
+
+export default useComplaintEvents;
@@ -28,10 +28,13 @@ const Login = () => {
       setLoginError(false);
       sessionStorage.setItem("token", data.response.token);
       sessionStorage.setItem("userID", data.response.user_id);
+      sessionStorage.setItem("role", data.response.role);
       if (data.response.role === "S") {
         navigate("/student-dashboard");
       } else if (data.response.role === "L") {
         navigate('/lecturer-dashboard');
+      } else if (data.response.role === "A") {
+        navigate('/advisor-dashboard');
       } else if (data.response.role === "H") {
         navigate('/hod-dashboard');
       } else if (data.response.role === "B") {